@@ -0,0 +1,168 @@
+// Package apidiff classifies changes of exported API as compatible or incompatible
+// under Go compatibility rules.
+package apidiff
+
+import (
+	"go/ast"
+	"strings"
+
+	"github.com/vetcher/godecl/diff"
+	"github.com/vetcher/godecl/types"
+)
+
+// Finding is a change of exported API.
+type Finding struct {
+	Name    string `json:"name"` // Name of changed entity, e.g. `Service.Get` or `User.Name`.
+	Message string `json:"message"`
+}
+
+func (f Finding) String() string {
+	return f.Name + ": " + f.Message
+}
+
+type Report struct {
+	Incompatible []Finding `json:"incompatible,omitempty"`
+	Compatible   []Finding `json:"compatible,omitempty"`
+}
+
+// Compares exported API of two packages, represented as lists of files.
+func Compare(old, new []*types.File) *Report {
+	return Classify(diff.Packages(old, new))
+}
+
+// Classifies changes of changeset, skipping unexported entities.
+func Classify(cs *diff.Changeset) *Report {
+	r := &Report{}
+	for _, c := range cs.Changes {
+		if !isExportedDecl(c) {
+			continue
+		}
+		switch c.Entity {
+		case diff.EntityInterface:
+			r.classifyInterface(c)
+		case diff.EntityStruct:
+			r.classifyStruct(c)
+		default:
+			r.classifyDecl(c.Name, c)
+		}
+	}
+	return r
+}
+
+func isExportedDecl(c diff.Change) bool {
+	for _, part := range strings.Split(c.Name, ".") {
+		if !ast.IsExported(part) {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *Report) add(compatible bool, name string, c diff.Change) {
+	f := Finding{Name: name, Message: message(c)}
+	if compatible {
+		r.Compatible = append(r.Compatible, f)
+	} else {
+		r.Incompatible = append(r.Incompatible, f)
+	}
+}
+
+// Classifies change of constant, variable, type, function or method.
+func (r *Report) classifyDecl(name string, c diff.Change) {
+	switch c.Kind {
+	case diff.Added:
+		r.add(true, name, c)
+	case diff.Removed:
+		r.add(false, name, c)
+	case diff.Modified:
+		switch c.Entity {
+		case diff.EntityVar:
+			r.add(sameType(c), name, c)
+		case diff.EntityFunction, diff.EntityMethod:
+			r.add(compatibleSignature(c), name, c)
+		default:
+			r.add(false, name, c)
+		}
+	}
+}
+
+// Any change of methods of exported interface breaks either callers or implementations,
+// except renaming of params and results.
+func (r *Report) classifyInterface(c diff.Change) {
+	if c.Kind != diff.Modified {
+		r.classifyDecl(c.Name, c)
+		return
+	}
+	for _, m := range c.Changes {
+		r.add(m.Kind == diff.Modified && compatibleSignature(m), c.Name+"."+m.Name, m)
+	}
+}
+
+// Reports whether changes of function or method keep it compatible: params and results are only renamed
+// and pointer receiver is changed to value one, which extends method set of value type.
+func compatibleSignature(c diff.Change) bool {
+	for _, cc := range c.Changes {
+		switch {
+		case cc.Kind == diff.Renamed:
+		case cc.Entity == diff.EntityReceiver && strings.TrimPrefix(cc.Old, "*") == cc.New:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func (r *Report) classifyStruct(c diff.Change) {
+	if c.Kind != diff.Modified {
+		r.classifyDecl(c.Name, c)
+		return
+	}
+	for _, f := range c.Changes {
+		if !ast.IsExported(f.Name) {
+			continue
+		}
+		name := c.Name + "." + f.Name
+		switch {
+		case f.Kind == diff.Added:
+			r.add(true, name, f)
+		case f.Kind == diff.Modified && f.Old == "" && f.New == "":
+			// Only tags were changed.
+			r.add(true, name, f)
+		default:
+			r.add(false, name, f)
+		}
+	}
+}
+
+// Reports whether variable keeps its type. Type of variable, assigned by result of call, is unknown,
+// so change of its value is compatible.
+func sameType(c diff.Change) bool {
+	old, ok := c.OldDecl.(*types.Variable)
+	if !ok {
+		return false
+	}
+	new, ok := c.NewDecl.(*types.Variable)
+	return ok && types.Identical(old.Type, new.Type)
+}
+
+func message(c diff.Change) string {
+	msg := string(c.Kind) + " " + c.Entity
+	switch c.Kind {
+	case diff.Added:
+		msg += ": " + c.New
+	case diff.Removed:
+		msg += ": " + c.Old
+	case diff.Modified, diff.Renamed:
+		if c.Old != "" || c.New != "" {
+			msg += ": " + c.Old + " -> " + c.New
+		}
+		var parts []string
+		for _, cc := range c.Changes {
+			parts = append(parts, cc.Name+" "+message(cc))
+		}
+		if len(parts) > 0 {
+			msg += " (" + strings.Join(parts, "; ") + ")"
+		}
+	}
+	return msg
+}
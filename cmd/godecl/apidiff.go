@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/build"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/vetcher/godecl"
+	"github.com/vetcher/godecl/apidiff"
+	"github.com/vetcher/godecl/types"
+)
+
+// Compares exported API of all packages of two source trees.
+// Exits with 1, when incompatible changes are found.
+func apidiffCmd(args []string) int {
+	fs := flag.NewFlagSet("apidiff", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "print report as JSON")
+	all := fs.Bool("all", false, "print compatible changes too")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: godecl apidiff [flags] old new\n\nold and new are package directories or roots of source trees.")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return 2
+	}
+	oldTree, err := loadTree(fs.Arg(0))
+	if err != nil {
+		return fatalf("%v", err)
+	}
+	newTree, err := loadTree(fs.Arg(1))
+	if err != nil {
+		return fatalf("%v", err)
+	}
+	reports := make(map[string]*apidiff.Report)
+	incompatible := false
+	for _, dir := range treeDirs(oldTree, newTree) {
+		r := apidiff.Compare(oldTree[dir], newTree[dir])
+		if len(r.Incompatible) > 0 {
+			incompatible = true
+		}
+		if !*all {
+			r.Compatible = nil
+		}
+		if len(r.Incompatible) > 0 || len(r.Compatible) > 0 {
+			reports[dir] = r
+		}
+	}
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(reports); err != nil {
+			return fatalf("%v", err)
+		}
+	} else {
+		for _, dir := range treeDirs(oldTree, newTree) {
+			r, ok := reports[dir]
+			if !ok {
+				continue
+			}
+			fmt.Printf("%s\n", dir)
+			for _, f := range r.Incompatible {
+				fmt.Printf("\tincompatible: %s\n", f)
+			}
+			for _, f := range r.Compatible {
+				fmt.Printf("\tcompatible: %s\n", f)
+			}
+		}
+	}
+	if incompatible {
+		return 1
+	}
+	return 0
+}
+
+// Parses all non-main packages under root directory, keyed by their directories relative to root.
+// Testdata, vendor and internal directories are skipped.
+func loadTree(root string) (map[string][]*types.File, error) {
+	tree := make(map[string][]*types.File)
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			return nil
+		}
+		name := info.Name()
+		if path != root && (strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") ||
+			name == "testdata" || name == "vendor" || name == "internal") {
+			return filepath.SkipDir
+		}
+		pkg, err := build.ImportDir(path, 0)
+		if err != nil {
+			if _, ok := err.(*build.NoGoError); ok {
+				return nil
+			}
+			return err
+		}
+		if pkg.Name == "main" {
+			return nil
+		}
+		files, err := godecl.ParsePackage(path)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		tree[filepath.ToSlash(rel)] = files
+		return nil
+	})
+	return tree, err
+}
+
+func treeDirs(a, b map[string][]*types.File) []string {
+	var dirs []string
+	for dir := range a {
+		dirs = append(dirs, dir)
+	}
+	for dir := range b {
+		if _, ok := a[dir]; !ok {
+			dirs = append(dirs, dir)
+		}
+	}
+	sort.Strings(dirs)
+	return dirs
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Writes files of tree, keyed by paths relative to root, and returns root.
+func writeTree(t *testing.T, files map[string]string) string {
+	root, err := ioutil.TempDir("", "apidiff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, src := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestApidiffCmd(t *testing.T) {
+	old := writeTree(t, map[string]string{
+		"a/a.go":        "package a\n\nfunc F(a int) (n int) { return 0 }\n\nfunc G() {}\n",
+		"b/b.go":        "package b\n\ntype I interface{ M(x int) }\n",
+		"cmd/main.go":   "package main\n\nfunc Removed() {}\n\nfunc main() {}\n",
+		"internal/i.go": "package internal\n\nfunc Removed() {}\n",
+	})
+	defer os.RemoveAll(old)
+	renamed := writeTree(t, map[string]string{
+		"a/a.go":      "package a\n\nfunc F(b int) (m int) { return 0 }\n\nfunc G() {}\n",
+		"b/b.go":      "package b\n\ntype I interface{ M(y int) }\n",
+		"cmd/main.go": "package main\n\nfunc main() {}\n",
+	})
+	defer os.RemoveAll(renamed)
+	removed := writeTree(t, map[string]string{
+		"a/a.go": "package a\n\nfunc F(a int) (n int) { return 0 }\n",
+		"b/b.go": "package b\n\ntype I interface{ M(x int) }\n",
+	})
+	defer os.RemoveAll(removed)
+
+	stdout := os.Stdout
+	defer func() { os.Stdout = stdout }()
+	devnull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer devnull.Close()
+	os.Stdout = devnull
+
+	if code := apidiffCmd([]string{old, renamed}); code != 0 {
+		t.Errorf("renaming of params is reported as incompatible, exit code %d", code)
+	}
+	if code := apidiffCmd([]string{"-json", "-all", old, removed}); code != 1 {
+		t.Errorf("removing of function is not reported as incompatible, exit code %d", code)
+	}
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/vetcher/godecl/gen/decorator"
+)
+
+// Generates decorators, which call hooks around methods of interfaces of package.
+func decoratorCmd(args []string) int {
+	fs := flag.NewFlagSet("decorator", flag.ExitOnError)
+	ifaces := fs.String("i", "", "comma-separated names of interfaces, all interfaces of package by default")
+	var of outputFlags
+	of.register(fs)
+	var lf loadFlags
+	lf.register(fs)
+	fs.Usage = func() {
+		fmt.Fprint(os.Stderr, "Usage: godecl decorator [flags] [package]\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	pkg, found, loader, err := loadInterfaces(&lf, *ifaces, fs.Args())
+	if err != nil {
+		return fatalf("%v", err)
+	}
+	cfg := decorator.Config{Package: pkg.Name, PackagePath: pkg.Path, SourcePath: pkg.Path, Resolver: pkg.Scope(loader)}
+	if of.pkg != "" {
+		cfg.Package, cfg.PackagePath = of.pkg, of.pkgPath
+	}
+	src, err := decorator.Generate(cfg, found...)
+	if err != nil {
+		return fatalf("%v", err)
+	}
+	return of.write(src)
+}
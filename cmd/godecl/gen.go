@@ -0,0 +1,54 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/vetcher/godecl/gen/tmpl"
+)
+
+// Generates Go file by template over declarations of packages.
+func genCmd(args []string) int {
+	fs := flag.NewFlagSet("gen", flag.ExitOnError)
+	template := fs.String("t", "", "template file, see documentation of package github.com/vetcher/godecl/gen/tmpl for helpers")
+	var of outputFlags
+	of.register(fs)
+	var lf loadFlags
+	lf.register(fs)
+	fs.Usage = func() {
+		fmt.Fprint(os.Stderr, "Usage: godecl gen -t template [flags] [packages]\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if *template == "" {
+		fs.Usage()
+		return 2
+	}
+	text, err := ioutil.ReadFile(*template)
+	if err != nil {
+		return fatalf("%v", err)
+	}
+	patterns := fs.Args()
+	if len(patterns) == 0 {
+		patterns = []string{"."}
+	}
+	pkgs, loader, err := lf.load(patterns)
+	if err != nil {
+		return fatalf("%v", err)
+	}
+	if len(pkgs) == 0 {
+		return fatalf("no packages to generate from")
+	}
+	cfg := tmpl.Config{Package: pkgs[0].Name, PackagePath: pkgs[0].Path, SourcePath: pkgs[0].Path, Resolver: pkgs[0].Scope(loader)}
+	if of.pkg != "" {
+		cfg.Package, cfg.PackagePath = of.pkg, of.pkgPath
+	}
+	src, err := tmpl.Generate(cfg, filepath.Base(*template), string(text), pkgs...)
+	if err != nil {
+		return fatalf("%v", err)
+	}
+	return of.write(src)
+}
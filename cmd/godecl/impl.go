@@ -0,0 +1,85 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/vetcher/godecl/gen/impl"
+	"github.com/vetcher/godecl/types"
+)
+
+// Generates stubs of methods of interface for receiver type.
+func implCmd(args []string) int {
+	fs := flag.NewFlagSet("impl", flag.ExitOnError)
+	dir := fs.String("dir", ".", "directory of package, where receiver type is declared")
+	panics := fs.Bool("panic", false, `stubs panic with "not implemented" instead of returning zero values`)
+	var of outputFlags
+	of.register(fs)
+	var lf loadFlags
+	lf.register(fs)
+	fs.Usage = func() {
+		fmt.Fprint(os.Stderr, "Usage: godecl impl [flags] receiver interface\n\n"+
+			"Receiver is `s *Store`, `*Store` or `Store`. Interface is a name of interface of package in -dir,\n"+
+			"or an import path with name, e.g. `io.ReadCloser` or `github.com/acme/store.Store`.\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return 2
+	}
+	// Only interface is needed, so declarations, which can't be parsed, are skipped silently.
+	loader := lf.loader()
+	loader.Lenient = true
+	var local *types.Package
+	if of.pkg == "" {
+		var err error
+		if local, err = loader.LoadDir(*dir); err != nil {
+			return fatalf("%v", err)
+		}
+		of.pkg, of.pkgPath = local.Name, local.Path
+	}
+
+	// Interface is declared in package of receiver or is qualified by import path.
+	src := local
+	name := fs.Arg(1)
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		var err error
+		if src, err = loader.Import(name[:i]); err != nil {
+			return fatalf("%v", err)
+		}
+		name = name[i+1:]
+	} else if src == nil {
+		return fatalf("interface %s should be qualified by import path, when -pkg is set", name)
+	}
+	var iface *types.Interface
+	for _, file := range src.Files {
+		for i := range file.Interfaces {
+			if file.Interfaces[i].Name == name {
+				iface = &file.Interfaces[i]
+			}
+		}
+	}
+	if iface == nil {
+		return fatalf("interface %s is not found in package %s", name, src.Name)
+	}
+	if lf.lenient {
+		for _, err := range loader.Errors {
+			fmt.Fprintf(os.Stderr, "godecl: %v\n", err)
+		}
+	}
+	cfg := impl.Config{
+		Package:     of.pkg,
+		PackagePath: of.pkgPath,
+		SourcePath:  src.Path,
+		Resolver:    src.Scope(loader),
+		Panic:       *panics,
+	}
+	out, err := impl.Generate(cfg, fs.Arg(0), iface)
+	if err != nil {
+		return fatalf("%v", err)
+	}
+	return of.write(out)
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/vetcher/godecl/gen"
+	"github.com/vetcher/godecl/gen/jsonschema"
+)
+
+// Generates JSON Schema of configuration structure of package.
+func jsonschemaCmd(args []string) int {
+	fs := flag.NewFlagSet("jsonschema", flag.ExitOnError)
+	tag := fs.String("tag", "json", "tag, which names fields: json or yaml")
+	id := fs.String("id", "", "value of $id of schema")
+	output := fs.String("o", "", "output file, stdout by default")
+	var lf loadFlags
+	lf.register(fs)
+	fs.Usage = func() {
+		fmt.Fprint(os.Stderr, "Usage: godecl jsonschema [flags] structure [package]\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() < 1 {
+		fs.Usage()
+		return 2
+	}
+	if *tag != "json" && *tag != "yaml" {
+		return fatalf("unknown tag %q", *tag)
+	}
+	pkg, loader, err := loadPackage(&lf, fs.Args()[1:])
+	if err != nil {
+		return fatalf("%v", err)
+	}
+	structs, err := findStructs(pkg, fs.Arg(0))
+	if err != nil {
+		return fatalf("%v", err)
+	}
+	if len(structs) != 1 {
+		return fatalf("expected one structure, got %d", len(structs))
+	}
+	schema, err := jsonschema.Generate(jsonschema.Config{ID: *id, Tag: *tag, Package: pkg, Importer: loader}, structs[0])
+	if err != nil {
+		return fatalf("%v", err)
+	}
+	src, err := schema.JSON()
+	if err != nil {
+		return fatalf("%v", err)
+	}
+	if *output == "" {
+		os.Stdout.Write(src)
+	} else if err := gen.WriteFile(*output, src); err != nil {
+		return fatalf("%v", err)
+	}
+	return 0
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go/build"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/vetcher/godecl"
+	"github.com/vetcher/godecl/types"
+)
+
+// Flags, which control loading of packages.
+type loadFlags struct {
+	tests   bool
+	tags    string
+	lenient bool
+}
+
+func (f *loadFlags) register(fs *flag.FlagSet) {
+	fs.BoolVar(&f.tests, "tests", false, "include test files")
+	fs.StringVar(&f.tags, "tags", "", "comma-separated list of build tags")
+	fs.BoolVar(&f.lenient, "lenient", false, "skip declarations, which can't be parsed, and report them to stderr")
+}
+
+func (f *loadFlags) loader() *godecl.Loader {
+	ctx := build.Default
+	if f.tags != "" {
+		ctx.BuildTags = strings.Split(f.tags, ",")
+	}
+	return &godecl.Loader{Context: &ctx, Tests: f.tests, Lenient: f.lenient}
+}
+
+// Loads packages by patterns: files, directories, `dir/...` patterns or import paths.
+// Files of one directory, which go one after another, are loaded as one package.
+func (f *loadFlags) load(patterns []string) ([]*types.Package, *godecl.Loader, error) {
+	loader := f.loader()
+	var (
+		pkgs  []*types.Package
+		files []string
+	)
+	flush := func() error {
+		if len(files) == 0 {
+			return nil
+		}
+		pkg, err := loader.LoadFiles(files...)
+		if err != nil {
+			return err
+		}
+		pkgs, files = append(pkgs, pkg), nil
+		return nil
+	}
+	for _, pattern := range patterns {
+		if strings.HasSuffix(pattern, ".go") {
+			if len(files) > 0 && filepath.Dir(files[0]) != filepath.Dir(pattern) {
+				if err := flush(); err != nil {
+					return nil, nil, err
+				}
+			}
+			files = append(files, pattern)
+			continue
+		}
+		if err := flush(); err != nil {
+			return nil, nil, err
+		}
+		var (
+			loaded []*types.Package
+			err    error
+		)
+		switch {
+		case strings.HasSuffix(pattern, "/..."):
+			loaded, err = loadRecursive(loader, strings.TrimSuffix(pattern, "/..."))
+		case isDir(pattern):
+			var pkg *types.Package
+			pkg, err = loader.LoadDir(pattern)
+			loaded = []*types.Package{pkg}
+		default:
+			var pkg *types.Package
+			pkg, err = loader.Import(pattern)
+			loaded = []*types.Package{pkg}
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		pkgs = append(pkgs, loaded...)
+	}
+	if err := flush(); err != nil {
+		return nil, nil, err
+	}
+	for _, err := range loader.Errors {
+		fmt.Fprintf(os.Stderr, "godecl: %v\n", err)
+	}
+	return pkgs, loader, nil
+}
+
+// Loads packages in all directories under root, except testdata, vendor and hidden ones.
+func loadRecursive(loader *godecl.Loader, root string) ([]*types.Package, error) {
+	var pkgs []*types.Package
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			return nil
+		}
+		name := info.Name()
+		if path != root && (strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") ||
+			name == "testdata" || name == "vendor") {
+			return filepath.SkipDir
+		}
+		if _, err := loader.Context.ImportDir(path, 0); err != nil {
+			if _, ok := err.(*build.NoGoError); ok {
+				return nil
+			}
+			return err
+		}
+		pkg, err := loader.LoadDir(path)
+		if err != nil {
+			return err
+		}
+		pkgs = append(pkgs, pkg)
+		return nil
+	})
+	return pkgs, err
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
@@ -0,0 +1,73 @@
+// Command godecl inspects declarations of Go packages.
+//
+// Usage:
+//
+//	godecl [flags] [packages]
+//	godecl apidiff [flags] old new
+//	godecl query [flags] query [packages]
+//	godecl mock [flags] [package]
+//	godecl decorator [flags] [package]
+//	godecl gen -t template [flags] [packages]
+//	godecl impl [flags] receiver interface
+//	godecl proto [flags] [package]
+//	godecl openapi [flags] [package]
+//	godecl jsonschema [flags] structure [package]
+//
+// Packages are files, directories, `dir/...` patterns or import paths.
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+const usage = `Usage:
+	godecl [flags] [packages]	print declarations of packages
+	godecl apidiff [flags] old new	report incompatible changes of exported API
+	godecl query [flags] query [packages]	find declarations, which match query
+	godecl mock [flags] [package]	generate mocks of interfaces
+	godecl decorator [flags] [package]	generate decorators of interfaces with hooks around methods
+	godecl gen -t template [flags] [packages]	generate Go file by template over declarations
+	godecl impl [flags] receiver interface	generate stubs of methods of interface
+	godecl proto [flags] [package]	generate proto file from interfaces and structures
+	godecl openapi [flags] [package]	generate OpenAPI document from annotated interfaces and structures
+	godecl jsonschema [flags] structure [package]	generate JSON Schema of configuration structure
+
+Run 'godecl -h' for flags of printing.
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		os.Exit(printCmd(nil))
+	}
+	switch os.Args[1] {
+	case "apidiff":
+		os.Exit(apidiffCmd(os.Args[2:]))
+	case "query":
+		os.Exit(queryCmd(os.Args[2:]))
+	case "mock":
+		os.Exit(mockCmd(os.Args[2:]))
+	case "decorator":
+		os.Exit(decoratorCmd(os.Args[2:]))
+	case "gen":
+		os.Exit(genCmd(os.Args[2:]))
+	case "impl":
+		os.Exit(implCmd(os.Args[2:]))
+	case "proto":
+		os.Exit(protoCmd(os.Args[2:]))
+	case "openapi":
+		os.Exit(openapiCmd(os.Args[2:]))
+	case "jsonschema":
+		os.Exit(jsonschemaCmd(os.Args[2:]))
+	case "help":
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	default:
+		os.Exit(printCmd(os.Args[1:]))
+	}
+}
+
+func fatalf(format string, args ...interface{}) int {
+	fmt.Fprintf(os.Stderr, "godecl: "+format+"\n", args...)
+	return 2
+}
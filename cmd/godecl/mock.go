@@ -0,0 +1,37 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/vetcher/godecl/gen/mock"
+)
+
+// Generates mocks of interfaces of package.
+func mockCmd(args []string) int {
+	fs := flag.NewFlagSet("mock", flag.ExitOnError)
+	ifaces := fs.String("i", "", "comma-separated names of interfaces, all interfaces of package by default")
+	var of outputFlags
+	of.register(fs)
+	var lf loadFlags
+	lf.register(fs)
+	fs.Usage = func() {
+		fmt.Fprint(os.Stderr, "Usage: godecl mock [flags] [package]\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	pkg, found, loader, err := loadInterfaces(&lf, *ifaces, fs.Args())
+	if err != nil {
+		return fatalf("%v", err)
+	}
+	cfg := mock.Config{Package: pkg.Name, PackagePath: pkg.Path, SourcePath: pkg.Path, Resolver: pkg.Scope(loader)}
+	if of.pkg != "" {
+		cfg.Package, cfg.PackagePath = of.pkg, of.pkgPath
+	}
+	src, err := mock.Generate(cfg, found...)
+	if err != nil {
+		return fatalf("%v", err)
+	}
+	return of.write(src)
+}
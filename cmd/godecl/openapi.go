@@ -0,0 +1,69 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/vetcher/godecl/gen"
+	"github.com/vetcher/godecl/gen/openapi"
+	"github.com/vetcher/godecl/types"
+)
+
+// Generates OpenAPI document from annotated interfaces and structures of package.
+func openapiCmd(args []string) int {
+	fs := flag.NewFlagSet("openapi", flag.ExitOnError)
+	ifaces := fs.String("i", "", "comma-separated names of interfaces, all interfaces of package, when -s is not set")
+	structs := fs.String("s", "", "comma-separated names of structures")
+	title := fs.String("title", "", "title of API, name of package by default")
+	version := fs.String("version", "1.0.0", "version of API")
+	format := fs.String("format", "yaml", "output format: yaml or json")
+	output := fs.String("o", "", "output file, stdout by default")
+	var lf loadFlags
+	lf.register(fs)
+	fs.Usage = func() {
+		fmt.Fprint(os.Stderr, "Usage: godecl openapi [flags] [package]\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if *format != "yaml" && *format != "json" {
+		return fatalf("unknown format %q", *format)
+	}
+	pkg, loader, err := loadPackage(&lf, fs.Args())
+	if err != nil {
+		return fatalf("%v", err)
+	}
+	var found []*types.Interface
+	if *ifaces != "" || *structs == "" {
+		if found, err = findInterfaces(pkg, *ifaces); err != nil {
+			return fatalf("%v", err)
+		}
+	}
+	schemas, err := findStructs(pkg, *structs)
+	if err != nil {
+		return fatalf("%v", err)
+	}
+	cfg := openapi.Config{Title: *title, Version: *version, SourcePath: pkg.Path, Resolver: pkg.Scope(loader)}
+	if cfg.Title == "" {
+		cfg.Title = pkg.Name
+	}
+	doc, err := openapi.Generate(cfg, found, schemas)
+	if err != nil {
+		return fatalf("%v", err)
+	}
+	var src []byte
+	if *format == "json" {
+		src, err = doc.JSON()
+	} else {
+		src, err = doc.YAML()
+	}
+	if err != nil {
+		return fatalf("%v", err)
+	}
+	if *output == "" {
+		os.Stdout.Write(src)
+	} else if err := gen.WriteFile(*output, src); err != nil {
+		return fatalf("%v", err)
+	}
+	return 0
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/vetcher/godecl"
+	"github.com/vetcher/godecl/gen"
+	"github.com/vetcher/godecl/types"
+)
+
+// Flags of generators, which emit Go file.
+type outputFlags struct {
+	output  string
+	pkg     string
+	pkgPath string
+}
+
+func (f *outputFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.output, "o", "", "output file, stdout by default")
+	fs.StringVar(&f.pkg, "pkg", "", "package name of generated file, package of declarations by default")
+	fs.StringVar(&f.pkgPath, "pkgpath", "", "import path of package of generated file, when -pkg is set")
+}
+
+func (f *outputFlags) write(src []byte) int {
+	if f.output == "" {
+		os.Stdout.Write(src)
+		return 0
+	}
+	if err := gen.WriteFile(f.output, src); err != nil {
+		return fatalf("%v", err)
+	}
+	return 0
+}
+
+// Loads single package and its interfaces by comma-separated names.
+func loadInterfaces(lf *loadFlags, names string, patterns []string) (*types.Package, []*types.Interface, *godecl.Loader, error) {
+	pkg, loader, err := loadPackage(lf, patterns)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	ifaces, err := findInterfaces(pkg, names)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if len(ifaces) == 0 {
+		return nil, nil, nil, fmt.Errorf("package %s has no interfaces", pkg.Name)
+	}
+	return pkg, ifaces, loader, nil
+}
+
+// Loads single package by patterns, the current directory by default.
+func loadPackage(lf *loadFlags, patterns []string) (*types.Package, *godecl.Loader, error) {
+	if len(patterns) == 0 {
+		patterns = []string{"."}
+	}
+	pkgs, loader, err := lf.load(patterns)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(pkgs) != 1 {
+		return nil, nil, fmt.Errorf("expected one package, got %d", len(pkgs))
+	}
+	return pkgs[0], loader, nil
+}
+
+// Returns interfaces of package by comma-separated names, all interfaces, when names are empty.
+func findInterfaces(pkg *types.Package, names string) ([]*types.Interface, error) {
+	all := make(map[string]*types.Interface)
+	var ifaces []*types.Interface
+	for _, file := range pkg.Files {
+		for i := range file.Interfaces {
+			iface := &file.Interfaces[i]
+			all[iface.Name] = iface
+			if names == "" {
+				ifaces = append(ifaces, iface)
+			}
+		}
+	}
+	if names == "" {
+		return ifaces, nil
+	}
+	for _, name := range strings.Split(names, ",") {
+		iface, ok := all[name]
+		if !ok {
+			return nil, fmt.Errorf("interface %s is not found in package %s", name, pkg.Name)
+		}
+		ifaces = append(ifaces, iface)
+	}
+	return ifaces, nil
+}
+
+// Returns structures of package by comma-separated names.
+func findStructs(pkg *types.Package, names string) ([]*types.Struct, error) {
+	if names == "" {
+		return nil, nil
+	}
+	all := make(map[string]*types.Struct)
+	for _, file := range pkg.Files {
+		for i := range file.Structures {
+			all[file.Structures[i].Name] = &file.Structures[i]
+		}
+	}
+	var structs []*types.Struct
+	for _, name := range strings.Split(names, ",") {
+		s, ok := all[name]
+		if !ok {
+			return nil, fmt.Errorf("structure %s is not found in package %s", name, pkg.Name)
+		}
+		structs = append(structs, s)
+	}
+	return structs, nil
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/vetcher/godecl/internal/yaml"
+	"github.com/vetcher/godecl/printer"
+	"github.com/vetcher/godecl/types"
+)
+
+// Prints declarations of packages in one of formats.
+func printCmd(args []string) int {
+	fs := flag.NewFlagSet("godecl", flag.ExitOnError)
+	format := fs.String("format", "json", "output format: json, yaml, outline or signatures")
+	exported := fs.Bool("exported", false, "print exported declarations only")
+	var lf loadFlags
+	lf.register(fs)
+	fs.Usage = func() {
+		fmt.Fprint(os.Stderr, "Usage: godecl [flags] [files | dirs | dir/... | import paths]\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	patterns := fs.Args()
+	if len(patterns) == 0 {
+		patterns = []string{"."}
+	}
+	pkgs, _, err := lf.load(patterns)
+	if err != nil {
+		return fatalf("%v", err)
+	}
+	if *exported {
+		for _, pkg := range pkgs {
+			for i, f := range pkg.Files {
+				pkg.Files[i] = types.ExportedFile(f)
+			}
+		}
+	}
+	switch *format {
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(pkgs)
+	case "yaml":
+		var data []byte
+		if data, err = yaml.Marshal(pkgs); err == nil {
+			_, err = os.Stdout.Write(data)
+		}
+	case "outline":
+		for _, pkg := range pkgs {
+			writeOutline(os.Stdout, pkg)
+		}
+	case "signatures":
+		for _, pkg := range pkgs {
+			writeSignatures(os.Stdout, pkg)
+		}
+	default:
+		return fatalf("unknown format %q", *format)
+	}
+	if err != nil {
+		return fatalf("%v", err)
+	}
+	return 0
+}
+
+// Writes indented tree of declarations: packages, their declarations, fields and methods.
+func writeOutline(w io.Writer, pkg *types.Package) {
+	fmt.Fprintf(w, "package %s %s\n", pkg.Name, pkg.Path)
+	for _, f := range pkg.Files {
+		for _, c := range f.Constants {
+			fmt.Fprintf(w, "\tconst %s\n", printer.VariableString(&c))
+		}
+		for _, vars := range printer.GroupVars(f.Vars) {
+			fmt.Fprintf(w, "\tvar %s\n", printer.VariableString(vars...))
+		}
+		for _, t := range f.Types {
+			fmt.Fprintf(w, "\ttype %s %s\n", t.Name, printer.TypeString(t.Type))
+			writeMethods(w, t.Methods)
+		}
+		for _, i := range f.Interfaces {
+			fmt.Fprintf(w, "\tinterface %s\n", i.Name)
+			for _, e := range i.Embedded {
+				fmt.Fprintf(w, "\t\tembedded %s\n", printer.TypeString(e))
+			}
+			for _, m := range i.Methods {
+				fmt.Fprintf(w, "\t\tmethod %s\n", printer.FuncSignature(m))
+			}
+		}
+		for _, s := range f.Structures {
+			fmt.Fprintf(w, "\tstruct %s\n", s.Name)
+			for _, field := range s.Fields {
+				str := printer.TypeString(field.Type)
+				if field.Name != "" {
+					str = field.Name + " " + str
+				}
+				if field.RawTags != "" {
+					str += " " + field.RawTags
+				}
+				fmt.Fprintf(w, "\t\tfield %s\n", str)
+			}
+			writeMethods(w, s.Methods)
+		}
+		for _, fn := range f.Functions {
+			fmt.Fprintf(w, "\tfunc %s\n", printer.FuncSignature(&fn))
+		}
+	}
+}
+
+func writeMethods(w io.Writer, methods []*types.Method) {
+	for _, m := range methods {
+		fmt.Fprintf(w, "\t\tmethod %s\n", printer.FuncSignature(&m.Function))
+	}
+}
+
+// Writes one line for each declaration, prefixed by package path.
+func writeSignatures(w io.Writer, pkg *types.Package) {
+	prefix := pkg.Path
+	if prefix == "" {
+		prefix = pkg.Name
+	}
+	for _, f := range pkg.Files {
+		for _, c := range f.Constants {
+			fmt.Fprintf(w, "%s: const %s\n", prefix, printer.VariableString(&c))
+		}
+		for _, vars := range printer.GroupVars(f.Vars) {
+			fmt.Fprintf(w, "%s: var %s\n", prefix, printer.VariableString(vars...))
+		}
+		for _, t := range f.Types {
+			fmt.Fprintf(w, "%s: type %s %s\n", prefix, t.Name, printer.TypeString(t.Type))
+		}
+		for _, i := range f.Interfaces {
+			fmt.Fprintf(w, "%s: type %s interface\n", prefix, i.Name)
+			for _, m := range i.Methods {
+				fmt.Fprintf(w, "%s: func (%s) %s\n", prefix, i.Name, printer.FuncSignature(m))
+			}
+		}
+		for _, s := range f.Structures {
+			fmt.Fprintf(w, "%s: type %s struct\n", prefix, s.Name)
+		}
+		for _, fn := range f.Functions {
+			fmt.Fprintf(w, "%s: func %s\n", prefix, printer.FuncSignature(&fn))
+		}
+		for _, m := range f.Methods {
+			recv := printer.TypeString(m.Receiver.Type)
+			if m.Receiver.Name != "" {
+				recv = m.Receiver.Name + " " + recv
+			}
+			fmt.Fprintf(w, "%s: func (%s) %s\n", prefix, recv, printer.FuncSignature(&m.Function))
+		}
+	}
+}
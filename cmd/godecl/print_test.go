@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const printSource = `// Package a docs.
+package a
+
+import "io"
+
+// Answer docs.
+const Answer = 42
+
+var r, W = io.Pipe()
+
+var w, x = io.Pipe()
+
+type ID int
+
+func (ID) String() string { return "" }
+
+type Store interface {
+	Get(id ID) (*User, error)
+}
+
+type User struct {
+	Name string ` + "`json:\"name\"`" + `
+	age  int
+}
+
+func New(s Store) *User { return nil }
+`
+
+// Runs command and returns its output.
+func capture(t *testing.T, cmd func([]string) int, args ...string) string {
+	out, err := ioutil.TempFile("", "print")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(out.Name())
+	defer out.Close()
+	stdout := os.Stdout
+	os.Stdout = out
+	code := cmd(args)
+	os.Stdout = stdout
+	if code != 0 {
+		t.Fatalf("%v: exit code %d", args, code)
+	}
+	data, err := ioutil.ReadFile(out.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestPrintCmd(t *testing.T) {
+	root := writeTree(t, map[string]string{"a/a.go": printSource})
+	defer os.RemoveAll(root)
+	dir := filepath.Join(root, "a")
+
+	for _, tc := range []struct {
+		args     []string
+		expected string
+	}{
+		{
+			args: []string{"-format", "outline"},
+			expected: "package a \n" +
+				"\tconst Answer = 42\n" +
+				"\tvar r, W = io.Pipe()\n" +
+				"\tvar w, x = io.Pipe()\n" +
+				"\ttype ID int\n" +
+				"\t\tmethod String() string\n" +
+				"\tinterface Store\n" +
+				"\t\tmethod Get(id ID) (*User, error)\n" +
+				"\tstruct User\n" +
+				"\t\tfield Name string `json:\"name\"`\n" +
+				"\t\tfield age int\n" +
+				"\tfunc New(s Store) *User\n",
+		},
+		{
+			args: []string{"-format", "outline", "-exported"},
+			expected: "package a \n" +
+				"\tconst Answer = 42\n" +
+				"\tvar r, W = io.Pipe()\n" +
+				"\ttype ID int\n" +
+				"\t\tmethod String() string\n" +
+				"\tinterface Store\n" +
+				"\t\tmethod Get(id ID) (*User, error)\n" +
+				"\tstruct User\n" +
+				"\t\tfield Name string `json:\"name\"`\n" +
+				"\tfunc New(s Store) *User\n",
+		},
+		{
+			args: []string{"-format", "signatures"},
+			expected: "a: const Answer = 42\n" +
+				"a: var r, W = io.Pipe()\n" +
+				"a: var w, x = io.Pipe()\n" +
+				"a: type ID int\n" +
+				"a: type Store interface\n" +
+				"a: func (Store) Get(id ID) (*User, error)\n" +
+				"a: type User struct\n" +
+				"a: func New(s Store) *User\n" +
+				"a: func (ID) String() string\n",
+		},
+	} {
+		if out := capture(t, printCmd, append(tc.args, dir)...); out != tc.expected {
+			t.Errorf("%v:\n%s\nexpected:\n%s", tc.args, out, tc.expected)
+		}
+	}
+
+	var pkgs []struct {
+		Name  string
+		Files []struct {
+			Constants []struct{ Name, Value string }
+		}
+	}
+	out := capture(t, printCmd, "-format", "json", dir)
+	if err := json.Unmarshal([]byte(out), &pkgs); err != nil {
+		t.Fatalf("%v\n%s", err, out)
+	}
+	if len(pkgs) != 1 || pkgs[0].Name != "a" || len(pkgs[0].Files) != 1 || len(pkgs[0].Files[0].Constants) != 1 ||
+		pkgs[0].Files[0].Constants[0].Value != "42" {
+		t.Errorf("unexpected json:\n%s", out)
+	}
+
+	out = capture(t, printCmd, "-format", "yaml", dir)
+	for _, s := range []string{
+		"- name: a\n  files:\n  - schema_version: ",
+		"    docs:\n    - // Package a docs.\n",
+		"    constants:\n    - name: Answer\n",
+		"      value: \"42\"\n",
+		"        raw: \"`json:\\\"name\\\"`\"\n",
+	} {
+		if !strings.Contains(out, s) {
+			t.Errorf("yaml does not contain %q:\n%s", s, out)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/vetcher/godecl/gen"
+	"github.com/vetcher/godecl/gen/proto"
+	"github.com/vetcher/godecl/types"
+)
+
+// Generates proto file with services of interfaces and messages of structures of package.
+func protoCmd(args []string) int {
+	fs := flag.NewFlagSet("proto", flag.ExitOnError)
+	ifaces := fs.String("i", "", "comma-separated names of interfaces, all interfaces of package, when -s is not set")
+	structs := fs.String("s", "", "comma-separated names of structures")
+	pkgName := fs.String("package", "", "proto package, name of Go package by default")
+	goPackage := fs.String("go_package", "", "value of option go_package, import path of Go package by default")
+	lockFile := fs.String("lock", "", "file, which keeps numbers of fields between generations")
+	output := fs.String("o", "", "output file, stdout by default")
+	var lf loadFlags
+	lf.register(fs)
+	fs.Usage = func() {
+		fmt.Fprint(os.Stderr, "Usage: godecl proto [flags] [package]\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	pkg, loader, err := loadPackage(&lf, fs.Args())
+	if err != nil {
+		return fatalf("%v", err)
+	}
+	var found []*types.Interface
+	if *ifaces != "" || *structs == "" {
+		if found, err = findInterfaces(pkg, *ifaces); err != nil {
+			return fatalf("%v", err)
+		}
+	}
+	messages, err := findStructs(pkg, *structs)
+	if err != nil {
+		return fatalf("%v", err)
+	}
+	cfg := proto.Config{Package: *pkgName, GoPackage: *goPackage, SourcePath: pkg.Path, Resolver: pkg.Scope(loader)}
+	if cfg.Package == "" {
+		cfg.Package = pkg.Name
+	}
+	if cfg.GoPackage == "" {
+		cfg.GoPackage = pkg.Path
+	}
+	if *lockFile != "" {
+		if cfg.Lock, err = proto.ReadLock(*lockFile); err != nil {
+			return fatalf("%v", err)
+		}
+	}
+	src, err := proto.Generate(cfg, found, messages)
+	if err != nil {
+		return fatalf("%v", err)
+	}
+	if *output == "" {
+		os.Stdout.Write(src)
+	} else if err := gen.WriteFile(*output, src); err != nil {
+		return fatalf("%v", err)
+	}
+	if cfg.Lock != nil {
+		if err := cfg.Lock.Write(*lockFile); err != nil {
+			return fatalf("%v", err)
+		}
+	}
+	return 0
+}
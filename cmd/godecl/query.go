@@ -0,0 +1,56 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/vetcher/godecl/query"
+)
+
+// Prints entities of packages, which match query.
+func queryCmd(args []string) int {
+	fs := flag.NewFlagSet("query", flag.ExitOnError)
+	names := fs.Bool("names", false, "print qualified names of entities, one per line, instead of JSON")
+	var lf loadFlags
+	lf.register(fs)
+	fs.Usage = func() {
+		fmt.Fprint(os.Stderr, "Usage: godecl query [flags] query [packages]\n\nSee documentation of package github.com/vetcher/godecl/query for query syntax.\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() < 1 {
+		fs.Usage()
+		return 2
+	}
+	q, err := query.Parse(fs.Arg(0))
+	if err != nil {
+		return fatalf("%v", err)
+	}
+	patterns := fs.Args()[1:]
+	if len(patterns) == 0 {
+		patterns = []string{"."}
+	}
+	pkgs, loader, err := lf.load(patterns)
+	if err != nil {
+		return fatalf("%v", err)
+	}
+	q.Importer = loader
+	found := q.Find(pkgs...)
+	if *names {
+		for _, e := range found {
+			fmt.Println(e.Name)
+		}
+		return 0
+	}
+	if found == nil {
+		found = []*query.Entity{}
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(found); err != nil {
+		return fatalf("%v", err)
+	}
+	return 0
+}
@@ -0,0 +1,392 @@
+// Package diff computes structural difference between two declarations models.
+package diff
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/vetcher/godecl/printer"
+	"github.com/vetcher/godecl/types"
+)
+
+type ChangeKind string
+
+const (
+	Added    ChangeKind = "added"
+	Removed  ChangeKind = "removed"
+	Modified ChangeKind = "modified"
+	// Only name of param or result was changed.
+	Renamed ChangeKind = "renamed"
+)
+
+// Kinds of changed entities.
+const (
+	EntityConst     = "const"
+	EntityVar       = "var"
+	EntityType      = "type"
+	EntityInterface = "interface"
+	EntityStruct    = "struct"
+	EntityFunction  = "function"
+	EntityMethod    = "method"
+	EntityField     = "field"
+	EntityTag       = "tag"
+	EntityParam     = "param"
+	EntityResult    = "result"
+	EntityReceiver  = "receiver"
+	EntityEmbedded  = "embedded"
+)
+
+// Change describes one added, removed or modified entity.
+// Modified structures, interfaces, functions and methods contain changes of their parts.
+type Change struct {
+	Kind   ChangeKind `json:"kind"`
+	Entity string     `json:"entity"`
+	// Name of entity. Methods are named `Receiver.Method`, params and results are named by
+	// their names or by position, e.g. `#0`, when they are anonymous.
+	Name    string   `json:"name"`
+	Old     string   `json:"old,omitempty"` // Representation of entity before change.
+	New     string   `json:"new,omitempty"` // Representation of entity after change.
+	Changes []Change `json:"changes,omitempty"`
+	// Declarations of entity before and after change, e.g. *types.Variable or *types.StructField.
+	// They are set for declarations, fields and methods of interfaces, but not for params, receivers and tags.
+	OldDecl interface{} `json:"-"`
+	NewDecl interface{} `json:"-"`
+}
+
+// Changeset is a list of changes of top-level declarations, sorted by entity kind and name.
+type Changeset struct {
+	Changes []Change `json:"changes,omitempty"`
+}
+
+func (c *Changeset) Empty() bool {
+	return len(c.Changes) == 0
+}
+
+// Compares two files.
+func Files(old, new *types.File) *Changeset {
+	return Packages([]*types.File{old}, []*types.File{new})
+}
+
+// Compares two packages, represented as lists of files.
+func Packages(old, new []*types.File) *Changeset {
+	o, n := collect(old), collect(new)
+	var changes []Change
+	for _, entity := range entities {
+		changes = append(changes, compareSets(entity, o[entity], n[entity])...)
+	}
+	return &Changeset{Changes: changes}
+}
+
+// Order of top-level entities in changeset.
+var entities = []string{EntityConst, EntityVar, EntityType, EntityInterface, EntityStruct, EntityFunction, EntityMethod}
+
+// Collects top-level declarations by entity kind and name.
+func collect(files []*types.File) map[string]map[string]interface{} {
+	decls := make(map[string]map[string]interface{})
+	for _, entity := range entities {
+		decls[entity] = make(map[string]interface{})
+	}
+	for _, f := range files {
+		for i := range f.Constants {
+			decls[EntityConst][f.Constants[i].Name] = &f.Constants[i]
+		}
+		for i := range f.Vars {
+			decls[EntityVar][f.Vars[i].Name] = &f.Vars[i]
+		}
+		for i := range f.Types {
+			decls[EntityType][f.Types[i].Name] = &f.Types[i]
+		}
+		for i := range f.Interfaces {
+			decls[EntityInterface][f.Interfaces[i].Name] = &f.Interfaces[i]
+		}
+		for i := range f.Structures {
+			decls[EntityStruct][f.Structures[i].Name] = &f.Structures[i]
+		}
+		for i := range f.Functions {
+			decls[EntityFunction][f.Functions[i].Name] = &f.Functions[i]
+		}
+		for i := range f.Methods {
+			decls[EntityMethod][MethodName(&f.Methods[i])] = &f.Methods[i]
+		}
+	}
+	delete(decls[EntityConst], "_")
+	delete(decls[EntityVar], "_")
+	return decls
+}
+
+// Returns name of method in form `Receiver.Method`.
+func MethodName(m *types.Method) string {
+	if recv := types.TypeName(m.Receiver.Type); recv != nil {
+		return *recv + "." + m.Name
+	}
+	return m.Name
+}
+
+func compareSets(entity string, old, new map[string]interface{}) []Change {
+	var changes []Change
+	for _, name := range unionKeys(old, new) {
+		o, inOld := old[name]
+		n, inNew := new[name]
+		switch {
+		case !inOld:
+			changes = append(changes, Change{Kind: Added, Entity: entity, Name: name, New: describe(n), NewDecl: n})
+		case !inNew:
+			changes = append(changes, Change{Kind: Removed, Entity: entity, Name: name, Old: describe(o), OldDecl: o})
+		default:
+			if c := compare(entity, name, o, n); c != nil {
+				c.OldDecl, c.NewDecl = o, n
+				changes = append(changes, *c)
+			}
+		}
+	}
+	return changes
+}
+
+func unionKeys(a, b map[string]interface{}) []string {
+	var keys []string
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Returns short representation of declaration.
+func describe(decl interface{}) string {
+	switch d := decl.(type) {
+	case *types.Variable:
+		return variableString(d)
+	case *types.FileType:
+		return typeString(d.Type)
+	case *types.Interface:
+		return "interface"
+	case *types.Struct:
+		return "struct"
+	case *types.Function:
+		return d.String()
+	case *types.Method:
+		return d.String()
+	case *types.StructField:
+		return fieldString(d)
+	}
+	return ""
+}
+
+// Returns nil, when declarations are equal.
+func compare(entity, name string, old, new interface{}) *Change {
+	var changes []Change
+	switch o := old.(type) {
+	case *types.Variable:
+		if variableString(o) == variableString(new.(*types.Variable)) {
+			return nil
+		}
+	case *types.FileType:
+		if typeString(o.Type) == typeString(new.(*types.FileType).Type) {
+			return nil
+		}
+	case *types.Function:
+		changes = compareFunctions(o, new.(*types.Function))
+		if len(changes) == 0 {
+			return nil
+		}
+	case *types.Method:
+		n := new.(*types.Method)
+		if typeString(o.Receiver.Type) != typeString(n.Receiver.Type) {
+			changes = append(changes, Change{
+				Kind:   Modified,
+				Entity: EntityReceiver,
+				Name:   n.Receiver.Name,
+				Old:    typeString(o.Receiver.Type),
+				New:    typeString(n.Receiver.Type),
+			})
+		}
+		changes = append(changes, compareFunctions(&o.Function, &n.Function)...)
+		if len(changes) == 0 {
+			return nil
+		}
+	case *types.Interface:
+		n := new.(*types.Interface)
+		changes = compareEmbedded(o.Embedded, n.Embedded)
+		changes = append(changes, compareSets(EntityMethod, interfaceMethods(o), interfaceMethods(n))...)
+		if len(changes) == 0 {
+			return nil
+		}
+		return &Change{Kind: Modified, Entity: entity, Name: name, Changes: changes}
+	case *types.Struct:
+		changes = compareSets(EntityField, structFields(o), structFields(new.(*types.Struct)))
+		if len(changes) == 0 {
+			return nil
+		}
+		return &Change{Kind: Modified, Entity: entity, Name: name, Changes: changes}
+	case *types.StructField:
+		n := new.(*types.StructField)
+		if typeString(o.Type) == typeString(n.Type) {
+			changes = compareTags(o.Tags, n.Tags)
+			if len(changes) == 0 {
+				return nil
+			}
+			return &Change{Kind: Modified, Entity: entity, Name: name, Changes: changes}
+		}
+		changes = compareTags(o.Tags, n.Tags)
+	}
+	return &Change{Kind: Modified, Entity: entity, Name: name, Old: describe(old), New: describe(new), Changes: changes}
+}
+
+func compareFunctions(old, new *types.Function) []Change {
+	changes := compareParams(EntityParam, old.Args, new.Args)
+	return append(changes, compareParams(EntityResult, old.Results, new.Results)...)
+}
+
+// Compares params by their positions.
+func compareParams(entity string, old, new []types.Variable) []Change {
+	var changes []Change
+	for i := 0; i < len(old) || i < len(new); i++ {
+		switch {
+		case i >= len(old):
+			changes = append(changes, Change{Kind: Added, Entity: entity, Name: paramName(new[i], i), New: new[i].String()})
+		case i >= len(new):
+			changes = append(changes, Change{Kind: Removed, Entity: entity, Name: paramName(old[i], i), Old: old[i].String()})
+		case typeString(old[i].Type) != typeString(new[i].Type):
+			changes = append(changes, Change{
+				Kind:   Modified,
+				Entity: entity,
+				Name:   paramName(new[i], i),
+				Old:    old[i].String(),
+				New:    new[i].String(),
+			})
+		case old[i].Name != new[i].Name:
+			changes = append(changes, Change{
+				Kind:   Renamed,
+				Entity: entity,
+				Name:   paramName(new[i], i),
+				Old:    old[i].String(),
+				New:    new[i].String(),
+			})
+		}
+	}
+	return changes
+}
+
+func paramName(v types.Variable, i int) string {
+	if v.Name != "" {
+		return v.Name
+	}
+	return "#" + strconv.Itoa(i)
+}
+
+// Embedded interfaces can be only added or removed.
+func compareEmbedded(old, new []types.Type) []Change {
+	o, n := make(map[string]bool), make(map[string]bool)
+	for _, t := range old {
+		o[typeString(t)] = true
+	}
+	for _, t := range new {
+		n[typeString(t)] = true
+	}
+	var changes []Change
+	for _, t := range old {
+		if name := typeString(t); !n[name] {
+			changes = append(changes, Change{Kind: Removed, Entity: EntityEmbedded, Name: name, Old: name})
+		}
+	}
+	for _, t := range new {
+		if name := typeString(t); !o[name] {
+			changes = append(changes, Change{Kind: Added, Entity: EntityEmbedded, Name: name, New: name})
+		}
+	}
+	return changes
+}
+
+func compareTags(old, new map[string][]string) []Change {
+	var changes []Change
+	for _, key := range unionTagKeys(old, new) {
+		o, inOld := old[key]
+		n, inNew := new[key]
+		switch {
+		case !inOld:
+			changes = append(changes, Change{Kind: Added, Entity: EntityTag, Name: key, New: strings.Join(n, ",")})
+		case !inNew:
+			changes = append(changes, Change{Kind: Removed, Entity: EntityTag, Name: key, Old: strings.Join(o, ",")})
+		case strings.Join(o, ",") != strings.Join(n, ","):
+			changes = append(changes, Change{
+				Kind:   Modified,
+				Entity: EntityTag,
+				Name:   key,
+				Old:    strings.Join(o, ","),
+				New:    strings.Join(n, ","),
+			})
+		}
+	}
+	return changes
+}
+
+func unionTagKeys(a, b map[string][]string) []string {
+	var keys []string
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func interfaceMethods(i *types.Interface) map[string]interface{} {
+	methods := make(map[string]interface{})
+	for _, m := range i.Methods {
+		methods[m.Name] = m
+	}
+	return methods
+}
+
+// Returns fields by names. Embedded fields are named by their type names.
+func structFields(s *types.Struct) map[string]interface{} {
+	fields := make(map[string]interface{})
+	for i := range s.Fields {
+		fields[FieldName(&s.Fields[i])] = &s.Fields[i]
+	}
+	return fields
+}
+
+// Returns name of field or name of type for embedded fields.
+func FieldName(f *types.StructField) string {
+	if f.Name != "" {
+		return f.Name
+	}
+	if name := types.TypeName(f.Type); name != nil {
+		return *name
+	}
+	return typeString(f.Type)
+}
+
+func typeString(t types.Type) string {
+	if t == nil {
+		return ""
+	}
+	return printer.TypeString(t)
+}
+
+func variableString(v *types.Variable) string {
+	str := typeString(v.Type)
+	if v.Value != "" {
+		str += " = " + v.Value
+	}
+	return strings.TrimSpace(str)
+}
+
+func fieldString(f *types.StructField) string {
+	str := typeString(f.Type)
+	if f.RawTags != "" {
+		str += " " + f.RawTags
+	}
+	return str
+}
@@ -0,0 +1,166 @@
+// Package decorator generates decorators of interfaces, which call hooks around every method.
+//
+// For interface `Service` generator emits `ServiceDecorator` structure, which implements `Service`
+// by delegating to `Next` and calls `Before` and `After` hooks with name of method, its arguments and results.
+// Decorators of logging, metrics or tracing are hooks, decorators may be chained through `Next`:
+//
+//	var svc Service = &ServiceDecorator{Next: impl, After: logCall}
+//
+// Context of method, which accepts `context.Context` as argument, is passed to hooks and may be replaced by `Before`,
+// e.g. to start span of trace. Methods without context pass `context.Background()`.
+// Interfaces with methods `Next`, `Before` or `After` are reported as errors, because they conflict with fields.
+package decorator
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/vetcher/godecl/gen"
+	"github.com/vetcher/godecl/types"
+)
+
+// Config describes generated file.
+type Config struct {
+	// Name and import path of package of generated file.
+	Package     string
+	PackagePath string
+	// Import path of package, where interfaces are declared.
+	SourcePath string
+	// Finds embedded interfaces, may be nil.
+	Resolver types.Resolver
+}
+
+// Returns source of file with decorators of interfaces.
+func Generate(cfg Config, ifaces ...*types.Interface) ([]byte, error) {
+	f := gen.NewFile(cfg.Package, cfg.PackagePath, cfg.SourcePath)
+	for _, iface := range ifaces {
+		if iface.Name == "" {
+			return nil, fmt.Errorf("interface should be named")
+		}
+		if err := generate(f, iface, cfg.Resolver); err != nil {
+			return nil, err
+		}
+	}
+	return f.Source()
+}
+
+// Fields of decorator.
+var fields = []string{"Next", "Before", "After"}
+
+func generate(f *gen.File, iface *types.Interface, r types.Resolver) error {
+	decorator := iface.Name + "Decorator"
+	fns := f.Methods(iface, r)
+	for _, fn := range fns {
+		for _, field := range fields {
+			if fn.Name == field {
+				return fmt.Errorf("method %s conflicts with field %s of %s", fn.Name, field, decorator)
+			}
+		}
+	}
+	contextPkg := f.Import("context")
+	ifaceType := f.Type(types.TName{TypeName: iface.Name})
+
+	f.Printf("var _ %s = (*%s)(nil)\n\n", ifaceType, decorator)
+	f.Printf("// %s implements %s by calling Next and hooks around its methods.\n", decorator, ifaceType)
+	f.Printf("type %s struct {\n", decorator)
+	f.Printf("Next %s\n", ifaceType)
+	f.Printf("// Before is called before method of Next with name of method and its arguments, except context.\n")
+	f.Printf("// Returned context is passed to method, when method accepts context.\n")
+	f.Printf("Before func(ctx %s.Context, method string, args []interface{}) %s.Context\n", contextPkg, contextPkg)
+	f.Printf("// After is called after method of Next with its arguments, results, except error, and returned error.\n")
+	f.Printf("After func(ctx %s.Context, method string, args, results []interface{}, err error)\n", contextPkg)
+	f.Printf("}\n\n")
+
+	for _, fn := range fns {
+		generateMethod(f, decorator, fn, contextPkg)
+	}
+	return nil
+}
+
+func generateMethod(f *gen.File, decorator string, fn *types.Function, contextPkg string) {
+	// Names, used in body of method, should not be shadowed by params.
+	reserved := map[string]bool{"d": true, "args": true, contextPkg: true}
+	// Unnamed context is named `ctx`.
+	args := append([]types.Variable(nil), fn.Args...)
+	for i := range args {
+		if gen.IsContext(args[i].Type) && (args[i].Name == "" || args[i].Name == "_") && !taken("ctx", args) {
+			args[i].Name = "ctx"
+			break
+		}
+	}
+	args = gen.Named(args, "p", reserved)
+
+	ctx, ctxIndex := "", -1
+	for i, a := range args {
+		if gen.IsContext(a.Type) {
+			ctx, ctxIndex = a.Name, i
+			break
+		}
+	}
+	if ctxIndex < 0 {
+		ctx = "ctx"
+		for i := 2; taken(ctx, args); i++ {
+			ctx = "ctx" + strconv.Itoa(i)
+		}
+		reserved[ctx] = true
+	}
+
+	// Unnamed error is named `err`, as it is usual for Go code.
+	results := append([]types.Variable(nil), fn.Results...)
+	errIndex := -1
+	if n := len(results); n > 0 && gen.IsError(results[n-1].Type) {
+		errIndex = n - 1
+		if results[errIndex].Name == "" || results[errIndex].Name == "_" {
+			results[errIndex].Name = "err"
+		}
+	}
+	results = gen.Named(results, "r", reserved, args)
+
+	var resultsStr string
+	if len(results) > 0 {
+		resultsStr = " (" + f.Params(results) + ")"
+	}
+	f.Printf("// %s calls %s of Next and hooks.\n", fn.Name, fn.Name)
+	f.Printf("func (d *%s) %s(%s)%s {\n", decorator, fn.Name, f.Params(args), resultsStr)
+	if ctxIndex < 0 {
+		f.Printf("%s := %s.Background()\n", ctx, contextPkg)
+	}
+	f.Printf("args := []interface{}{%s}\n", names(args, ctxIndex))
+	f.Printf("if d.Before != nil {\n%s = d.Before(%s, %q, args)\n}\n", ctx, ctx, fn.Name)
+	err := "nil"
+	if errIndex >= 0 {
+		err = results[errIndex].Name
+	}
+	f.Printf("if d.After != nil {\ndefer func() {\n")
+	f.Printf("d.After(%s, %q, args, []interface{}{%s}, %s)\n", ctx, fn.Name, names(results, errIndex), err)
+	f.Printf("}()\n}\n")
+	if len(results) > 0 {
+		f.Printf("return ")
+	}
+	f.Printf("d.Next.%s(%s)\n", fn.Name, gen.Args(args))
+	f.Printf("}\n\n")
+}
+
+// Returns names of vars, separated by commas, except var at index skip.
+func names(vars []types.Variable, skip int) string {
+	var s string
+	for i, v := range vars {
+		if i == skip {
+			continue
+		}
+		if s != "" {
+			s += ", "
+		}
+		s += v.Name
+	}
+	return s
+}
+
+func taken(name string, vars []types.Variable) bool {
+	for _, v := range vars {
+		if v.Name == name {
+			return true
+		}
+	}
+	return false
+}
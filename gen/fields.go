@@ -0,0 +1,220 @@
+package gen
+
+import (
+	"bytes"
+	"encoding/json"
+	"go/ast"
+	"strings"
+
+	"github.com/vetcher/godecl/types"
+)
+
+// Field is a field of structure, as encoding/json and compatible encoders see it.
+type Field struct {
+	types.StructField
+	// Name of field in encoded document.
+	Key       string
+	OmitEmpty bool
+	// Field is encoded as string by `,string` option.
+	Quoted bool
+	// Import path and resolver of package of structure, which declares field,
+	// it differs from package of the outer structure for fields of embedded structures.
+	Path  string
+	Scope types.Resolver
+
+	depth  int
+	tagged bool
+}
+
+// Returns fields of structure s from package path, as encoding/json encodes them, when tagKey is `json`,
+// or as gopkg.in/yaml does, when tagKey is `yaml`: unexported fields and fields with name `-` are skipped,
+// fields of embedded structures are promoted, fields of outer structures hide ones of embedded structures.
+// Embedded structures are found by resolver r.
+func EncodedFields(s *types.Struct, tagKey, path string, r types.Resolver) []Field {
+	fields := encodedFields(s, tagKey, path, r, 0, make(map[*types.Struct]bool))
+	byKey := make(map[string][]int)
+	for i, f := range fields {
+		byKey[f.Key] = append(byKey[f.Key], i)
+	}
+	var result []Field
+	for i, f := range fields {
+		if dominant(fields, byKey[f.Key]) == i {
+			result = append(result, f)
+		}
+	}
+	return result
+}
+
+// Returns index of field, which wins among fields with the same key, or -1, when fields are ambiguous.
+// The shallowest field wins, tagged one wins among fields of the same depth.
+func dominant(fields []Field, indexes []int) int {
+	best := -1
+	ambiguous := false
+	for _, i := range indexes {
+		switch {
+		case best < 0 || fields[i].depth < fields[best].depth:
+			best, ambiguous = i, false
+		case fields[i].depth == fields[best].depth && fields[i].tagged != fields[best].tagged:
+			if fields[i].tagged {
+				best = i
+			}
+			ambiguous = false
+		case fields[i].depth == fields[best].depth:
+			ambiguous = true
+		}
+	}
+	if ambiguous {
+		return -1
+	}
+	return best
+}
+
+func encodedFields(s *types.Struct, tagKey, path string, r types.Resolver, depth int, visited map[*types.Struct]bool) []Field {
+	if visited[s] {
+		return nil
+	}
+	visited[s] = true
+	defer delete(visited, s)
+	var fields []Field
+	for _, sf := range s.Fields {
+		tag := sf.Tags[tagKey]
+		var name string
+		if len(tag) > 0 {
+			name = tag[0]
+		}
+		if name == "-" && len(tag) == 1 {
+			continue
+		}
+		f := Field{StructField: sf, Key: name, Path: path, Scope: r, depth: depth, tagged: name != ""}
+		for _, option := range options(tag) {
+			switch option {
+			case "omitempty":
+				f.OmitEmpty = true
+			case "string":
+				f.Quoted = true
+			}
+		}
+		if sf.Name == "" {
+			t := sf.Type
+			if p, ok := t.(types.TPointer); ok {
+				t = p.Next
+			}
+			typeName := types.TypeName(t)
+			if typeName == nil {
+				continue
+			}
+			// Structures are promoted, when they are not named by tag. Yaml promotes only inline ones.
+			promote := name == ""
+			if tagKey == "yaml" {
+				promote = hasOption(tag, "inline")
+			}
+			if promote {
+				if decl, declPath, scope := Resolve(t, path, r); decl != nil {
+					if embedded, ok := decl.(*types.Struct); ok {
+						fields = append(fields, encodedFields(embedded, tagKey, declPath, scope, depth+1, visited)...)
+						continue
+					}
+				}
+			}
+			if !ast.IsExported(*typeName) {
+				continue
+			}
+			f.Name = *typeName
+		} else if !ast.IsExported(sf.Name) {
+			continue
+		}
+		if f.Key == "" {
+			f.Key = f.Name
+			if tagKey == "yaml" {
+				f.Key = strings.ToLower(f.Name)
+			}
+		}
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+// Returns options of tag, which follow name.
+func options(tag []string) []string {
+	if len(tag) == 0 {
+		return nil
+	}
+	return tag[1:]
+}
+
+func hasOption(tag []string, option string) bool {
+	for _, o := range options(tag) {
+		if o == option {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns declaration of named type t from package path, import path of package of declaration
+// and resolver of that package. Returns nil declaration, when type is not found or r is nil.
+func Resolve(t types.Type, path string, r types.Resolver) (decl interface{}, declPath string, scope types.Resolver) {
+	if r == nil {
+		return nil, "", nil
+	}
+	decl, scope = r.Resolve(t)
+	if decl == nil {
+		return nil, "", nil
+	}
+	if imp, ok := t.(types.TImport); ok && imp.Import != nil {
+		path = imp.Import.Package
+	}
+	return decl, path, scope
+}
+
+// Object is a JSON object, which keeps order of members.
+type Object []Member
+
+type Member struct {
+	Key   string
+	Value interface{}
+}
+
+// Sets value of member with key or appends new member.
+func (o *Object) Set(key string, value interface{}) {
+	for i := range *o {
+		if (*o)[i].Key == key {
+			(*o)[i].Value = value
+			return
+		}
+	}
+	*o = append(*o, Member{Key: key, Value: value})
+}
+
+// Returns value of member with key or nil.
+func (o Object) Get(key string) interface{} {
+	for _, m := range o {
+		if m.Key == key {
+			return m.Value
+		}
+	}
+	return nil
+}
+
+func (o Object) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, m := range o {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		key, err := json.Marshal(m.Key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(m.Value)
+		if err != nil {
+			return nil, err
+		}
+		b.Write(key)
+		b.WriteByte(':')
+		b.Write(value)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
@@ -0,0 +1,273 @@
+// Package gen contains helpers, shared by code generators of godecl.
+package gen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/vetcher/godecl/printer"
+	"github.com/vetcher/godecl/types"
+)
+
+// File accumulates body of generated Go file and plans its imports.
+type File struct {
+	bytes.Buffer
+	// Name of package of generated file.
+	Package string
+	// Import path of package of generated file. Types of this package are not qualified.
+	Path    string
+	Imports *types.ImportSet
+	// Import path of package, where unqualified types of model are declared.
+	// When it is empty, unqualified types are printed as is.
+	From string
+	// Comment at the top of file, it is omitted when empty.
+	Header string
+}
+
+// Header marks generated files, so tools and reviewers skip them.
+const Header = "// Code generated by godecl. DO NOT EDIT."
+
+// Returns file of package name with import path pkgPath.
+func NewFile(name, pkgPath, from string) *File {
+	return &File{Package: name, Path: pkgPath, Imports: types.NewImportSet(pkgPath), From: from, Header: Header}
+}
+
+func (f *File) Printf(format string, args ...interface{}) {
+	fmt.Fprintf(f, format, args...)
+}
+
+// Returns source of type, qualified by imports of file.
+func (f *File) Type(t types.Type) string {
+	return printer.TypeString(types.Rewrite(t, f.Imports.Rewriter(f.From)))
+}
+
+// Adds package to imports and returns its alias.
+func (f *File) Import(pkgPath string) string {
+	return f.Imports.AddPath(pkgPath)
+}
+
+// Returns all methods of interface, declared in package From, found by resolver r.
+// Unqualified types of methods, promoted from interfaces of other packages, are qualified by their packages.
+func (f *File) Methods(iface *types.Interface, r types.Resolver) []*types.Function {
+	var fns []*types.Function
+	for _, sel := range iface.Selections(r) {
+		fn := sel.Method
+		if sel.Package != "" && sel.Package != f.From {
+			fn = types.RewriteFunction(fn, types.Requalifier(sel.Package, f.From))
+		}
+		fns = append(fns, fn)
+	}
+	return fns
+}
+
+// Returns params in form `a int, b ...string`.
+func (f *File) Params(vars []types.Variable) string {
+	strs := make([]string, len(vars))
+	for i, v := range vars {
+		strs[i] = f.Type(v.Type)
+		if v.Name != "" {
+			strs[i] = v.Name + " " + strs[i]
+		}
+	}
+	return strings.Join(strs, ", ")
+}
+
+// Returns results of signature: nothing, single unnamed type or list of results in parens.
+func (f *File) Results(vars []types.Variable) string {
+	switch {
+	case len(vars) == 0:
+		return ""
+	case len(vars) == 1 && vars[0].Name == "":
+		return " " + f.Type(vars[0].Type)
+	}
+	return " (" + f.Params(vars) + ")"
+}
+
+// Returns formatted source of file with header, package clause and imports.
+func (f *File) Source() ([]byte, error) {
+	var src bytes.Buffer
+	if f.Header != "" {
+		src.WriteString(f.Header + "\n\n")
+	}
+	src.WriteString("package " + f.Package + "\n\n")
+	if imports := f.Imports.Imports(); len(imports) > 0 {
+		src.WriteString("import (\n")
+		for _, imp := range imports {
+			if imp.Name != types.AssumedPackageName(imp.Package) {
+				src.WriteString(imp.Name + " ")
+			}
+			src.WriteString(strconv.Quote(imp.Package) + "\n")
+		}
+		src.WriteString(")\n\n")
+	}
+	src.Write(f.Bytes())
+	formatted, err := format.Source(src.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("can't format generated source: %v\n%s", err, src.Bytes())
+	}
+	return formatted, nil
+}
+
+// Returns copy of params, where every param has unique name, which is not reserved:
+// unnamed and blank params are named by prefix and position, e.g. `p0`, conflicting ones get a number suffix.
+// Names of taken are reserved too.
+func Named(vars []types.Variable, prefix string, reserved map[string]bool, taken ...[]types.Variable) []types.Variable {
+	used := make(map[string]bool)
+	for name := range reserved {
+		used[name] = true
+	}
+	for _, t := range taken {
+		for _, v := range t {
+			used[v.Name] = true
+		}
+	}
+	named := make([]types.Variable, len(vars))
+	for i, v := range vars {
+		name := v.Name
+		if name == "" || name == "_" {
+			name = prefix + strconv.Itoa(i)
+		}
+		for j := 2; used[name] || token.IsKeyword(name); j++ {
+			name = v.Name + strconv.Itoa(j)
+			if v.Name == "" || v.Name == "_" {
+				name = prefix + strconv.Itoa(i) + "_" + strconv.Itoa(j)
+			}
+		}
+		used[name] = true
+		v.Name = name
+		named[i] = v
+	}
+	return named
+}
+
+// Returns names of params, separated by commas. Variadic param is followed by `...`.
+func Args(vars []types.Variable) string {
+	names := make([]string, len(vars))
+	for i, v := range vars {
+		names[i] = v.Name
+		if IsVariadic(v) {
+			names[i] += "..."
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+func IsVariadic(v types.Variable) bool {
+	_, ok := v.Type.(types.TEllipsis)
+	return ok
+}
+
+// Returns type of variadic param as slice, other types as is.
+func SliceOfVariadic(t types.Type) types.Type {
+	if e, ok := t.(types.TEllipsis); ok {
+		return types.TArray{IsSlice: true, Next: e.Next}
+	}
+	return t
+}
+
+// Returns name with upper first letter.
+func Exported(name string) string {
+	if name == "" {
+		return name
+	}
+	r := []rune(name)
+	r[0] = unicode.ToUpper(r[0])
+	return string(r)
+}
+
+// Returns name with lower first letter, or the whole leading acronym in lower case, e.g. `httpClient` for `HTTPClient`.
+func Unexported(name string) string {
+	r := []rune(name)
+	for i := range r {
+		if !unicode.IsUpper(r[i]) {
+			break
+		}
+		// The last upper letter of acronym starts the next word.
+		if i > 0 && i+1 < len(r) && unicode.IsLower(r[i+1]) {
+			break
+		}
+		r[i] = unicode.ToLower(r[i])
+	}
+	return string(r)
+}
+
+// Writes data to file atomically: data is written to temporary file in the same directory, which replaces the file.
+func WriteFile(filename string, data []byte) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(filename), "."+filepath.Base(filename)+".")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filename)
+}
+
+// Reports whether type is `context.Context`.
+func IsContext(t types.Type) bool {
+	imp, ok := t.(types.TImport)
+	if !ok || imp.Import == nil || imp.Import.Package != "context" {
+		return false
+	}
+	name, ok := imp.Next.(types.TName)
+	return ok && name.TypeName == "Context"
+}
+
+// Reports whether type is builtin `error`.
+func IsError(t types.Type) bool {
+	name, ok := t.(types.TName)
+	return ok && name.TypeName == "error"
+}
+
+// Splits name to lower case words by underscores, dashes, spaces and case, e.g. `HTTPServerID` to `http server id`.
+func Words(name string) []string {
+	var (
+		words []string
+		word  []rune
+	)
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, strings.ToLower(string(word)))
+			word = nil
+		}
+	}
+	r := []rune(name)
+	for i, c := range r {
+		switch {
+		case c == '_' || c == '-' || unicode.IsSpace(c):
+			flush()
+			continue
+		case unicode.IsUpper(c) && i > 0:
+			prevLower := unicode.IsLower(r[i-1]) || unicode.IsDigit(r[i-1])
+			// The last upper letter of acronym starts the next word.
+			acronymEnd := unicode.IsUpper(r[i-1]) && i+1 < len(r) && unicode.IsLower(r[i+1])
+			if prevLower || acronymEnd {
+				flush()
+			}
+		}
+		word = append(word, c)
+	}
+	flush()
+	return words
+}
+
+// Returns name in snake case, e.g. `user_id` for `UserID`.
+func Snake(name string) string {
+	return strings.Join(Words(name), "_")
+}
@@ -0,0 +1,166 @@
+// Package impl generates stubs of methods, which implement interface, like the impl tool.
+//
+// Stubs keep names of params of interface methods and return zero values of results
+// or panic with "not implemented".
+package impl
+
+import (
+	"fmt"
+	"go/token"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/vetcher/godecl/gen"
+	"github.com/vetcher/godecl/types"
+)
+
+// Config describes generated file.
+type Config struct {
+	// Name and import path of package of generated file, where receiver type is declared.
+	Package     string
+	PackagePath string
+	// Import path of package, where interface is declared.
+	SourcePath string
+	// Finds embedded interfaces and declarations of types of results in package of interface, may be nil.
+	Resolver types.Resolver
+	// Stubs panic instead of returning zero values.
+	Panic bool
+}
+
+// Returns source of file with stubs of methods of interface for receiver, e.g. `s *Store`, `*Store` or `Store`.
+// Name of receiver is the first letter of type, when it is omitted.
+func Generate(cfg Config, recv string, iface *types.Interface) ([]byte, error) {
+	recvName, recvType, err := parseReceiver(recv)
+	if err != nil {
+		return nil, err
+	}
+	f := gen.NewFile(cfg.Package, cfg.PackagePath, cfg.SourcePath)
+	// Stubs are written to be edited.
+	f.Header = ""
+	methods := f.Methods(iface, cfg.Resolver)
+	if recvName == "" {
+		recvName = receiverName(recvType, methods)
+	}
+	// Interface is named only by comments, so its package is not imported.
+	ifaceType := iface.Name
+	if cfg.SourcePath != "" && cfg.SourcePath != cfg.PackagePath {
+		ifaceType = types.AssumedPackageName(cfg.SourcePath) + "." + iface.Name
+	}
+	for _, fn := range methods {
+		generateMethod(f, cfg, recvName+" "+recvType, ifaceType, fn)
+	}
+	return f.Source()
+}
+
+// Splits receiver to name and type.
+func parseReceiver(recv string) (name, typ string, err error) {
+	fields := strings.Fields(recv)
+	switch len(fields) {
+	case 1:
+		typ = fields[0]
+	case 2:
+		name, typ = fields[0], fields[1]
+		if !token.IsIdentifier(name) {
+			return "", "", fmt.Errorf("invalid name of receiver %q", name)
+		}
+	default:
+		return "", "", fmt.Errorf("invalid receiver %q", recv)
+	}
+	if !token.IsIdentifier(strings.TrimPrefix(typ, "*")) {
+		return "", "", fmt.Errorf("invalid type of receiver %q", typ)
+	}
+	return name, typ, nil
+}
+
+// Returns the first letter of type in lower case, which does not conflict with params.
+func receiverName(typ string, methods []*types.Function) string {
+	base := string(unicode.ToLower([]rune(strings.TrimPrefix(typ, "*"))[0]))
+	name := base
+	for i := 2; paramNamed(name, methods); i++ {
+		name = base + strconv.Itoa(i)
+	}
+	return name
+}
+
+func paramNamed(name string, methods []*types.Function) bool {
+	for _, fn := range methods {
+		for _, vars := range [][]types.Variable{fn.Args, fn.Results} {
+			for _, v := range vars {
+				if v.Name == name {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func generateMethod(f *gen.File, cfg Config, recv, ifaceType string, fn *types.Function) {
+	var body string
+	if cfg.Panic {
+		body = "panic(\"not implemented\")\n"
+	} else if len(fn.Results) > 0 {
+		zeros := make([]string, len(fn.Results))
+		for i, v := range fn.Results {
+			zeros[i] = zeroValue(f, v.Type, cfg.Resolver)
+		}
+		body = "return " + strings.Join(zeros, ", ") + "\n"
+	}
+	f.Printf("// %s implements %s.\n", fn.Name, ifaceType)
+	f.Printf("func (%s) %s(%s)%s {\n%s}\n\n", recv, fn.Name, f.Params(fn.Args), f.Results(fn.Results), body)
+}
+
+// Returns expression of zero value of type. Named types are found by resolver to choose between `nil`,
+// `0`, `""`, `false` and composite literal. Zero value of unknown type is `*new(T)`.
+func zeroValue(f *gen.File, t types.Type, r types.Resolver) string {
+	switch zero := zeroOf(t, r, 0); zero {
+	case "{}":
+		return f.Type(t) + "{}"
+	case "":
+		return "*new(" + f.Type(t) + ")"
+	default:
+		return zero
+	}
+}
+
+// Limits depth of chains of defined types.
+const maxDepth = 16
+
+// Returns literal of zero value, "{}" for composite types or empty string, when type is unknown.
+func zeroOf(t types.Type, r types.Resolver, depth int) string {
+	switch tt := t.(type) {
+	case types.TPointer, types.TMap, types.TChan, types.TInterface:
+		return "nil"
+	case types.TArray:
+		if tt.IsSlice {
+			return "nil"
+		}
+		return "{}"
+	case types.TName:
+		switch tt.TypeName {
+		case "bool":
+			return "false"
+		case "string":
+			return `""`
+		case "error":
+			return "nil"
+		}
+		if types.IsBuiltinTypeString(tt.TypeName) {
+			return "0"
+		}
+	}
+	if r == nil || depth > maxDepth {
+		return ""
+	}
+	decl, scope := r.Resolve(t)
+	switch d := decl.(type) {
+	case *types.Struct:
+		return "{}"
+	case *types.Interface:
+		return "nil"
+	case *types.FileType:
+		return zeroOf(d.Type, scope, depth+1)
+	}
+	return ""
+}
@@ -0,0 +1,376 @@
+// Package jsonschema generates JSON Schema (draft 2020-12) of configuration structures.
+//
+// Properties are named by `json` or `yaml` tags, fields without `omitempty`, which are not pointers,
+// are required and docs become descriptions. Nested structures, including structures of imported packages,
+// are inlined, when they are used once, and are put to `$defs` otherwise. Defined types with constants
+// of the same package become enums.
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/parser"
+	"go/token"
+	"path"
+	"strings"
+
+	"github.com/vetcher/godecl/gen"
+	"github.com/vetcher/godecl/printer"
+	"github.com/vetcher/godecl/types"
+)
+
+// Draft of JSON Schema.
+const Draft = "https://json-schema.org/draft/2020-12/schema"
+
+// Config describes generated schema.
+type Config struct {
+	// Value of `$id`, it is omitted when empty.
+	ID string
+	// Tag, which names fields: `json` by default or `yaml`.
+	Tag string
+	// Package, where root structure is declared.
+	Package *types.Package
+	// Loads imported packages, may be nil.
+	Importer types.Importer
+}
+
+// Schema is a subset of JSON Schema.
+type Schema struct {
+	Schema               string             `json:"$schema,omitempty"`
+	ID                   string             `json:"$id,omitempty"`
+	Ref                  string             `json:"$ref,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	ContentEncoding      string             `json:"contentEncoding,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Properties           gen.Object         `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Defs                 map[string]*Schema `json:"$defs,omitempty"`
+}
+
+// Returns indented JSON of schema.
+func (s *Schema) JSON() ([]byte, error) {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// Returns schema of root structure of package of config.
+func Generate(cfg Config, root *types.Struct) (*Schema, error) {
+	if cfg.Tag == "" {
+		cfg.Tag = "json"
+	}
+	g := &generator{cfg: cfg, defs: make(map[string]*Schema), names: make(map[string]string), refs: make(map[string]int)}
+	g.rootKey = cfg.Package.Path + "." + root.Name
+	schema, err := g.object(root, cfg.Package.Path, cfg.Package.Scope(cfg.Importer))
+	if err != nil {
+		return nil, err
+	}
+	schema.Schema, schema.ID, schema.Title = Draft, cfg.ID, root.Name
+	g.inline(schema)
+	if len(g.defs) > 0 {
+		schema.Defs = g.defs
+	}
+	return schema, nil
+}
+
+type generator struct {
+	cfg     Config
+	rootKey string
+	defs    map[string]*Schema
+	// Keys of declarations by names of definitions and number of references to definitions.
+	names map[string]string
+	refs  map[string]int
+}
+
+// Returns schema of object with fields of structure s from package path, which types are found by r.
+func (g *generator) object(s *types.Struct, path string, r types.Resolver) (*Schema, error) {
+	schema := &Schema{Type: "object", Description: s.Text()}
+	for _, f := range gen.EncodedFields(s, g.cfg.Tag, path, r) {
+		fs, err := g.schema(f.Type, f.Path, f.Scope, 0)
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %v", s.Name, f.Name, err)
+		}
+		if f.Quoted && (fs.Type == "integer" || fs.Type == "number" || fs.Type == "boolean") {
+			fs.Type = "string"
+		}
+		if text := f.Text(); text != "" {
+			fs.Description = text
+		}
+		schema.Properties.Set(f.Key, fs)
+		if _, pointer := f.Type.(types.TPointer); !f.OmitEmpty && !pointer {
+			schema.Required = append(schema.Required, f.Key)
+		}
+	}
+	return schema, nil
+}
+
+// Limits depth of chains of defined types.
+const maxDepth = 16
+
+var builtins = map[string]string{
+	"bool": "boolean", "string": "string",
+	"int": "integer", "int64": "integer", "int32": "integer", "int16": "integer", "int8": "integer", "rune": "integer",
+	"uint": "integer", "uint64": "integer", "uint32": "integer", "uint16": "integer", "uint8": "integer", "byte": "integer",
+	"float64": "number", "float32": "number",
+}
+
+// Returns schema of type from package path, which types are found by r.
+func (g *generator) schema(t types.Type, path string, r types.Resolver, depth int) (*Schema, error) {
+	if depth > maxDepth {
+		return nil, fmt.Errorf("too deep definition of type %s", printer.TypeString(t))
+	}
+	switch tt := t.(type) {
+	case types.TPointer:
+		return g.schema(tt.Next, path, r, depth+1)
+	case types.TArray:
+		if name, ok := tt.Next.(types.TName); ok && tt.IsSlice && (name.TypeName == "byte" || name.TypeName == "uint8") {
+			return &Schema{Type: "string", ContentEncoding: "base64"}, nil
+		}
+		items, err := g.schema(tt.Next, path, r, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		s := &Schema{Type: "array", Items: items}
+		if !tt.IsSlice && !tt.IsEllipsis {
+			n := tt.ArrayLen
+			s.MinItems, s.MaxItems = &n, &n
+		}
+		return s, nil
+	case types.TMap:
+		value, err := g.schema(tt.Value, path, r, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Type: "object", AdditionalProperties: value}, nil
+	case types.TInterface:
+		return &Schema{}, nil
+	case types.TName:
+		if typ, ok := builtins[tt.TypeName]; ok {
+			return &Schema{Type: typ}, nil
+		}
+		if types.IsBuiltinTypeString(tt.TypeName) {
+			return nil, fmt.Errorf("unsupported type %s", tt.TypeName)
+		}
+	case types.TImport:
+		if name, ok := tt.Next.(types.TName); ok && tt.Import != nil && tt.Import.Package == "time" {
+			switch name.TypeName {
+			case "Time":
+				return &Schema{Type: "string", Format: "date-time"}, nil
+			case "Duration":
+				// Yaml decodes durations from strings like `1m30s`, json from nanoseconds.
+				if g.cfg.Tag == "yaml" {
+					return &Schema{Type: "string"}, nil
+				}
+				return &Schema{Type: "integer"}, nil
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unsupported type %s", printer.TypeString(t))
+	}
+	decl, declPath, scope := gen.Resolve(t, path, r)
+	switch d := decl.(type) {
+	case *types.Struct:
+		key := declPath + "." + d.Name
+		if key == g.rootKey {
+			return &Schema{Ref: "#"}, nil
+		}
+		name, ok := g.define(key, d.Name, declPath)
+		if !ok {
+			g.defs[name] = &Schema{}
+			s, err := g.object(d, declPath, scope)
+			if err != nil {
+				return nil, err
+			}
+			g.defs[name] = s
+		}
+		return g.ref(name), nil
+	case *types.FileType:
+		underlying, err := g.schema(d.Type, declPath, scope, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		enum := g.enum(d, declPath)
+		if len(enum) == 0 {
+			if underlying.Description == "" && underlying.Ref == "" {
+				underlying.Description = d.Text()
+			}
+			return underlying, nil
+		}
+		name, ok := g.define(declPath+"."+d.Name, d.Name, declPath)
+		if !ok {
+			underlying.Enum = enum
+			if underlying.Description == "" {
+				underlying.Description = d.Text()
+			}
+			g.defs[name] = underlying
+		}
+		return g.ref(name), nil
+	case *types.Interface:
+		return &Schema{}, nil
+	}
+	return nil, fmt.Errorf("can't find type %s", printer.TypeString(t))
+}
+
+// Returns name of definition of declaration with key and whether it is already defined.
+// Names of declarations of different packages are qualified by package on conflicts.
+func (g *generator) define(key, name, declPath string) (string, bool) {
+	for n, k := range g.names {
+		if k == key {
+			return n, true
+		}
+	}
+	if _, taken := g.names[name]; taken {
+		name = path.Base(declPath) + "." + name
+		if _, taken := g.names[name]; taken {
+			name = strings.Replace(declPath, "/", ".", -1) + "." + name
+		}
+	}
+	g.names[name] = key
+	return name, false
+}
+
+func (g *generator) ref(name string) *Schema {
+	g.refs[name]++
+	return &Schema{Ref: "#/$defs/" + name}
+}
+
+// Returns values of constants of package, which have type of declaration.
+func (g *generator) enum(t *types.FileType, declPath string) []interface{} {
+	pkg := g.cfg.Package
+	if declPath != pkg.Path {
+		if g.cfg.Importer == nil {
+			return nil
+		}
+		var err error
+		if pkg, err = g.cfg.Importer.Import(declPath); err != nil {
+			return nil
+		}
+	}
+	var values []interface{}
+	for _, f := range pkg.Files {
+		for _, c := range f.Constants {
+			if name, ok := c.Type.(types.TName); !ok || name.TypeName != t.Name {
+				continue
+			}
+			if !ast.IsExported(c.Name) && ast.IsExported(t.Name) {
+				continue
+			}
+			value, ok := constValue(c.Value)
+			if !ok {
+				return nil
+			}
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// Returns JSON value of constant literal.
+func constValue(src string) (interface{}, bool) {
+	expr, err := parser.ParseExpr(src)
+	if err != nil {
+		return nil, false
+	}
+	neg := false
+	if u, ok := expr.(*ast.UnaryExpr); ok && u.Op == token.SUB {
+		neg, expr = true, u.X
+	}
+	lit, ok := expr.(*ast.BasicLit)
+	if !ok {
+		if id, ok := expr.(*ast.Ident); ok && (id.Name == "true" || id.Name == "false") && !neg {
+			return id.Name == "true", true
+		}
+		return nil, false
+	}
+	v := constant.MakeFromLiteral(lit.Value, lit.Kind, 0)
+	if neg {
+		v = constant.UnaryOp(token.SUB, v, 0)
+	}
+	switch v.Kind() {
+	case constant.String:
+		return constant.StringVal(v), true
+	case constant.Int:
+		if n, exact := constant.Int64Val(v); exact {
+			return n, true
+		}
+	case constant.Float:
+		if f, exact := constant.Float64Val(v); exact {
+			return f, true
+		}
+	}
+	return nil, false
+}
+
+// Inlines definitions of structures, which are referenced once and are not recursive.
+func (g *generator) inline(root *Schema) {
+	inlined := make(map[string]bool)
+	for name, n := range g.refs {
+		if n == 1 && !g.recursive(name, g.defs[name], make(map[string]bool)) {
+			inlined[name] = true
+		}
+	}
+	var walk func(s *Schema)
+	walk = func(s *Schema) {
+		if s == nil {
+			return
+		}
+		if name := strings.TrimPrefix(s.Ref, "#/$defs/"); name != s.Ref && inlined[name] {
+			description := s.Description
+			*s = *g.defs[name]
+			if description != "" {
+				s.Description = description
+			}
+		}
+		walk(s.Items)
+		walk(s.AdditionalProperties)
+		for _, m := range s.Properties {
+			walk(m.Value.(*Schema))
+		}
+	}
+	walk(root)
+	for name, def := range g.defs {
+		if !inlined[name] {
+			walk(def)
+		}
+	}
+	for name := range inlined {
+		delete(g.defs, name)
+	}
+}
+
+// Reports whether definition references itself.
+func (g *generator) recursive(name string, s *Schema, visited map[string]bool) bool {
+	if s == nil {
+		return false
+	}
+	if ref := strings.TrimPrefix(s.Ref, "#/$defs/"); ref != s.Ref {
+		if ref == name {
+			return true
+		}
+		if visited[ref] {
+			return false
+		}
+		visited[ref] = true
+		return g.recursive(name, g.defs[ref], visited)
+	}
+	if g.recursive(name, s.Items, visited) || g.recursive(name, s.AdditionalProperties, visited) {
+		return true
+	}
+	for _, m := range s.Properties {
+		if g.recursive(name, m.Value.(*Schema), visited) {
+			return true
+		}
+	}
+	return false
+}
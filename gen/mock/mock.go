@@ -0,0 +1,237 @@
+// Package mock generates mock implementations of interfaces.
+//
+// For interface `Service` generator emits `ServiceMock` structure, which:
+//   - records calls with their arguments, available by `<Method>Calls()`;
+//   - calls stub function `<Method>Func`, when it is set;
+//   - returns results of expectations, registered by `Expect<Method>()`,
+//     which may be restricted by arguments, and reports unmet expectations by `Verify()`.
+//
+// Mocks are safe for concurrent use.
+package mock
+
+import (
+	"fmt"
+
+	"github.com/vetcher/godecl/gen"
+	"github.com/vetcher/godecl/types"
+)
+
+// Config describes generated file.
+type Config struct {
+	// Name and import path of package of generated file.
+	Package     string
+	PackagePath string
+	// Import path of package, where interfaces are declared.
+	SourcePath string
+	// Finds embedded interfaces, may be nil.
+	Resolver types.Resolver
+}
+
+// Returns source of file with mocks of interfaces.
+func Generate(cfg Config, ifaces ...*types.Interface) ([]byte, error) {
+	f := gen.NewFile(cfg.Package, cfg.PackagePath, cfg.SourcePath)
+	for _, iface := range ifaces {
+		if iface.Name == "" {
+			return nil, fmt.Errorf("interface should be named")
+		}
+		if err := generate(f, iface, cfg.Resolver); err != nil {
+			return nil, err
+		}
+	}
+	return f.Source()
+}
+
+// Names, used by generated methods.
+var reserved = map[string]bool{"m": true, "fn": true, "e": true, "call": true, "expected": true}
+
+type method struct {
+	*types.Function
+	args, results     []types.Variable
+	call, expectation string // Names of generated types.
+}
+
+func generate(f *gen.File, iface *types.Interface, r types.Resolver) error {
+	mock := iface.Name + "Mock"
+	fns := f.Methods(iface, r)
+	if err := checkMembers(mock, fns); err != nil {
+		return err
+	}
+	syncPkg, fmtPkg, stringsPkg := f.Import("sync"), f.Import("fmt"), f.Import("strings")
+	var reflectPkg string
+	if len(fns) > 0 {
+		reflectPkg = f.Import("reflect")
+	}
+
+	// Packages, used in bodies of methods, should not be shadowed by params.
+	names := map[string]bool{fmtPkg: true, reflectPkg: true}
+	for name := range reserved {
+		names[name] = true
+	}
+	var methods []method
+	for _, fn := range fns {
+		args := gen.Named(fn.Args, "p", names)
+		methods = append(methods, method{
+			Function:    fn,
+			args:        args,
+			results:     gen.Named(fn.Results, "r", names, args),
+			call:        mock + fn.Name + "Call",
+			expectation: mock + fn.Name + "Expectation",
+		})
+	}
+
+	ifaceType := f.Type(types.TName{TypeName: iface.Name})
+	f.Printf("var _ %s = (*%s)(nil)\n\n", ifaceType, mock)
+	f.Printf("// %s is a mock implementation of %s.\n", mock, ifaceType)
+	f.Printf("type %s struct {\n", mock)
+	for _, m := range methods {
+		f.Printf("// %sFunc is called by %s, when no expectation matches the call.\n", m.Name, m.Name)
+		f.Printf("%sFunc func(%s)%s\n", m.Name, f.Params(m.args), f.Results(m.Results))
+	}
+	f.Printf("\nmu %s.Mutex\n", syncPkg)
+	f.Printf("calls struct {\n")
+	for _, m := range methods {
+		f.Printf("%s []%s\n", m.Name, m.call)
+	}
+	f.Printf("}\n")
+	f.Printf("expectations struct {\n")
+	for _, m := range methods {
+		f.Printf("%s []*%s\n", m.Name, m.expectation)
+	}
+	f.Printf("}\n")
+	f.Printf("unexpected []string\n")
+	f.Printf("}\n\n")
+
+	for _, m := range methods {
+		generateMethod(f, mock, ifaceType, m, fmtPkg, reflectPkg)
+	}
+
+	f.Printf("// Verify returns error, when some expectations are not met or some calls are not expected.\n")
+	f.Printf("func (m *%s) Verify() error {\n", mock)
+	f.Printf("m.mu.Lock()\ndefer m.mu.Unlock()\n")
+	f.Printf("problems := append([]string(nil), m.unexpected...)\n")
+	for _, m := range methods {
+		f.Printf("for _, e := range m.expectations.%s {\n", m.Name)
+		f.Printf("if !e.called {\nproblems = append(problems, %s.Sprintf(\"%s: expected call %s%%s is not made\", e.describe()))\n}\n}\n", fmtPkg, mock, m.Name)
+	}
+	f.Printf("if len(problems) > 0 {\nreturn %s.Errorf(\"%%d problems:\\n%%s\", len(problems), %s.Join(problems, \"\\n\"))\n}\n", fmtPkg, stringsPkg)
+	f.Printf("return nil\n}\n\n")
+	return nil
+}
+
+// Returns error, when method of interface has the same name as field or helper method of mock.
+func checkMembers(mock string, fns []*types.Function) error {
+	members := map[string]string{
+		"Verify":       "method Verify",
+		"mu":           "field mu",
+		"calls":        "field calls",
+		"expectations": "field expectations",
+		"unexpected":   "field unexpected",
+	}
+	for _, fn := range fns {
+		members["Expect"+fn.Name] = "method Expect" + fn.Name
+		members[fn.Name+"Calls"] = "method " + fn.Name + "Calls"
+		members[fn.Name+"Func"] = "field " + fn.Name + "Func"
+	}
+	for _, fn := range fns {
+		if member, ok := members[fn.Name]; ok {
+			return fmt.Errorf("method %s conflicts with %s of %s", fn.Name, member, mock)
+		}
+	}
+	return nil
+}
+
+func generateMethod(f *gen.File, mock, ifaceType string, m method, fmtPkg, reflectPkg string) {
+	// Structure of call arguments.
+	f.Printf("// %s holds arguments of call of %s.%s.\n", m.call, mock, m.Name)
+	f.Printf("type %s struct {\n", m.call)
+	for _, a := range m.args {
+		f.Printf("%s %s\n", gen.Exported(a.Name), f.Type(gen.SliceOfVariadic(a.Type)))
+	}
+	f.Printf("}\n\n")
+
+	// Expectation.
+	f.Printf("// %s is an expected call of %s.%s.\n", m.expectation, mock, m.Name)
+	f.Printf("type %s struct {\n", m.expectation)
+	f.Printf("args *%s\n", m.call)
+	if len(m.results) > 0 {
+		f.Printf("results struct {\n")
+		for _, r := range m.results {
+			f.Printf("%s %s\n", gen.Exported(r.Name), f.Type(r.Type))
+		}
+		f.Printf("}\n")
+	}
+	f.Printf("called bool\n")
+	f.Printf("}\n\n")
+
+	if len(m.args) > 0 {
+		f.Printf("// WithArgs restricts expectation to calls with equal arguments.\n")
+		f.Printf("func (e *%s) WithArgs(%s) *%s {\n", m.expectation, f.Params(m.args), m.expectation)
+		f.Printf("e.args = &%s{%s}\nreturn e\n}\n\n", m.call, callFields(m.args))
+	}
+	if len(m.results) > 0 {
+		f.Printf("// Return sets results of expected call.\n")
+		f.Printf("func (e *%s) Return(%s) *%s {\n", m.expectation, f.Params(m.results), m.expectation)
+		for _, r := range m.results {
+			f.Printf("e.results.%s = %s\n", gen.Exported(r.Name), r.Name)
+		}
+		f.Printf("return e\n}\n\n")
+	}
+	f.Printf("func (e *%s) describe() string {\n", m.expectation)
+	f.Printf("if e.args == nil {\nreturn \"(...)\"\n}\n")
+	f.Printf("return %s.Sprintf(\"%%+v\", *e.args)\n}\n\n", fmtPkg)
+
+	f.Printf("// Expect%s registers expected call of %s. Expectations are matched in order of registration.\n", m.Name, m.Name)
+	f.Printf("func (m *%s) Expect%s() *%s {\n", mock, m.Name, m.expectation)
+	f.Printf("e := &%s{}\n", m.expectation)
+	f.Printf("m.mu.Lock()\nm.expectations.%s = append(m.expectations.%s, e)\nm.mu.Unlock()\n", m.Name, m.Name)
+	f.Printf("return e\n}\n\n")
+
+	// Method itself.
+	f.Printf("// %s implements %s.\n", m.Name, ifaceType)
+	results := ""
+	if len(m.results) > 0 {
+		results = " (" + f.Params(m.results) + ")"
+	}
+	f.Printf("func (m *%s) %s(%s)%s {\n", mock, m.Name, f.Params(m.args), results)
+	f.Printf("call := %s{%s}\n", m.call, callFields(m.args))
+	f.Printf("m.mu.Lock()\n")
+	f.Printf("m.calls.%s = append(m.calls.%s, call)\n", m.Name, m.Name)
+	f.Printf("var expected *%s\n", m.expectation)
+	f.Printf("for _, e := range m.expectations.%s {\n", m.Name)
+	f.Printf("if !e.called && (e.args == nil || %s.DeepEqual(*e.args, call)) {\n", reflectPkg)
+	f.Printf("e.called, expected = true, e\nbreak\n}\n}\n")
+	f.Printf("fn := m.%sFunc\n", m.Name)
+	f.Printf("if expected == nil && fn == nil && len(m.expectations.%s) > 0 {\n", m.Name)
+	f.Printf("m.unexpected = append(m.unexpected, %s.Sprintf(\"%s: unexpected call %s%%+v\", call))\n}\n", fmtPkg, mock, m.Name)
+	f.Printf("m.mu.Unlock()\n")
+	f.Printf("if expected != nil {\n")
+	for _, r := range m.results {
+		f.Printf("%s = expected.results.%s\n", r.Name, gen.Exported(r.Name))
+	}
+	f.Printf("return\n}\n")
+	f.Printf("if fn != nil {\n")
+	if len(m.results) > 0 {
+		f.Printf("return fn(%s)\n", gen.Args(m.args))
+	} else {
+		f.Printf("fn(%s)\n", gen.Args(m.args))
+	}
+	f.Printf("}\n")
+	f.Printf("return\n}\n\n")
+
+	f.Printf("// %sCalls returns arguments of all calls of %s.\n", m.Name, m.Name)
+	f.Printf("func (m *%s) %sCalls() []%s {\n", mock, m.Name, m.call)
+	f.Printf("m.mu.Lock()\ndefer m.mu.Unlock()\n")
+	f.Printf("return append([]%s(nil), m.calls.%s...)\n}\n\n", m.call, m.Name)
+}
+
+// Returns keyed fields of call structure, e.g. `Ctx: ctx, Id: id`.
+func callFields(args []types.Variable) string {
+	var s string
+	for i, a := range args {
+		if i > 0 {
+			s += ", "
+		}
+		s += gen.Exported(a.Name) + ": " + a.Name
+	}
+	return s
+}
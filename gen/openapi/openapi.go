@@ -0,0 +1,603 @@
+// Package openapi generates OpenAPI 3 documents from structures and annotated interfaces.
+//
+// Structures become component schemas: properties are named by `json` tags, fields without `omitempty`,
+// which are not pointers, or fields with `validate:"required"` are required, docs become descriptions.
+// Tags `validate` of github.com/go-playground/validator set constraints: `min`, `max`, `len`, `gt`, `gte`,
+// `lt`, `lte`, `oneof` and formats `email`, `url`, `uri`, `uuid`, `ipv4`, `ipv6`.
+//
+// Method of interface becomes operation, when it is annotated by route:
+//
+//	// Get returns user by id.
+//	// @route GET /users/{id}
+//	// @tag users
+//	// @success 200
+//	Get(ctx context.Context, id int) (*User, error)
+//
+// Operation id is name of interface and method, e.g. `UsersGet`.
+// Arguments, except context, named as params of path, are path parameters. Other arguments of GET, HEAD and DELETE
+// operations are query parameters, fields of structure arguments are expanded. Other operations accept the only
+// argument or object of arguments as body. The only result, except error, or object of named results is
+// the response, error adds default response.
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/vetcher/godecl/gen"
+	"github.com/vetcher/godecl/internal/yaml"
+	"github.com/vetcher/godecl/printer"
+	"github.com/vetcher/godecl/types"
+)
+
+// Version of OpenAPI specification.
+const Version = "3.0.3"
+
+// Config describes generated document.
+type Config struct {
+	// Title and version of API.
+	Title   string
+	Version string
+	// Import path of package, where interfaces and structures are declared.
+	SourcePath string
+	// Finds declarations of types, may be nil.
+	Resolver types.Resolver
+}
+
+// Document is an OpenAPI document.
+type Document struct {
+	OpenAPI    string      `json:"openapi"`
+	Info       Info        `json:"info"`
+	Paths      gen.Object  `json:"paths"`
+	Components *Components `json:"components,omitempty"`
+}
+
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas,omitempty"`
+}
+
+// Schema is a subset of OpenAPI schema object.
+type Schema struct {
+	Ref                  string        `json:"$ref,omitempty"`
+	AllOf                []*Schema     `json:"allOf,omitempty"`
+	Type                 string        `json:"type,omitempty"`
+	Format               string        `json:"format,omitempty"`
+	Description          string        `json:"description,omitempty"`
+	Nullable             bool          `json:"nullable,omitempty"`
+	Enum                 []interface{} `json:"enum,omitempty"`
+	Minimum              *float64      `json:"minimum,omitempty"`
+	ExclusiveMinimum     bool          `json:"exclusiveMinimum,omitempty"`
+	Maximum              *float64      `json:"maximum,omitempty"`
+	ExclusiveMaximum     bool          `json:"exclusiveMaximum,omitempty"`
+	MinLength            *int          `json:"minLength,omitempty"`
+	MaxLength            *int          `json:"maxLength,omitempty"`
+	MinItems             *int          `json:"minItems,omitempty"`
+	MaxItems             *int          `json:"maxItems,omitempty"`
+	Items                *Schema       `json:"items,omitempty"`
+	Properties           gen.Object    `json:"properties,omitempty"`
+	Required             []string      `json:"required,omitempty"`
+	AdditionalProperties *Schema       `json:"additionalProperties,omitempty"`
+}
+
+// Operation is an OpenAPI operation object.
+type Operation struct {
+	Tags        []string             `json:"tags,omitempty"`
+	Summary     string               `json:"summary,omitempty"`
+	Description string               `json:"description,omitempty"`
+	OperationID string               `json:"operationId"`
+	Parameters  []*Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                  `json:"required,omitempty"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Returns JSON of document.
+func (d *Document) JSON() ([]byte, error) {
+	data, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// Returns YAML of document.
+func (d *Document) YAML() ([]byte, error) {
+	return yaml.Marshal(d)
+}
+
+// Returns document with operations of annotated methods of interfaces and schemas of structures,
+// including structures, which are used by them.
+func Generate(cfg Config, ifaces []*types.Interface, structs []*types.Struct) (*Document, error) {
+	g := &generator{cfg: cfg, schemas: make(map[string]*Schema), keys: make(map[string]string)}
+	doc := &Document{OpenAPI: Version, Info: Info{Title: cfg.Title, Version: cfg.Version}, Paths: gen.Object{}}
+	for _, iface := range ifaces {
+		for _, fn := range iface.AllMethods(cfg.Resolver) {
+			route, ok := fn.Annotation("route")
+			if !ok {
+				continue
+			}
+			method, path, op, err := g.operation(iface, fn, route)
+			if err != nil {
+				return nil, fmt.Errorf("%s.%s: %v", iface.Name, fn.Name, err)
+			}
+			item, _ := doc.Paths.Get(path).(map[string]*Operation)
+			if item == nil {
+				item = make(map[string]*Operation)
+				doc.Paths.Set(path, item)
+			}
+			if _, ok := item[method]; ok {
+				return nil, fmt.Errorf("%s.%s: operation %s %s is declared twice", iface.Name, fn.Name, strings.ToUpper(method), path)
+			}
+			item[method] = op
+		}
+	}
+	for _, s := range structs {
+		if _, err := g.structRef(s, cfg.SourcePath, cfg.Resolver); err != nil {
+			return nil, err
+		}
+	}
+	if len(g.schemas) > 0 {
+		doc.Components = &Components{Schemas: g.schemas}
+	}
+	return doc, nil
+}
+
+type generator struct {
+	cfg     Config
+	schemas map[string]*Schema
+	// Keys of declarations of schemas by their names, to find conflicts of structures of different packages.
+	keys map[string]string
+}
+
+var (
+	methods    = map[string]bool{"get": true, "put": true, "post": true, "delete": true, "options": true, "head": true, "patch": true, "trace": true}
+	pathParams = regexp.MustCompile(`\{([^{}]+)\}`)
+)
+
+func (g *generator) operation(iface *types.Interface, fn *types.Function, route string) (method, path string, op *Operation, err error) {
+	fields := strings.Fields(route)
+	if len(fields) != 2 || !methods[strings.ToLower(fields[0])] || !strings.HasPrefix(fields[1], "/") {
+		return "", "", nil, fmt.Errorf("route should be in form `METHOD /path`, got %q", route)
+	}
+	method, path = strings.ToLower(fields[0]), fields[1]
+	op = &Operation{OperationID: iface.Name + fn.Name, Responses: make(map[string]*Response)}
+	if text := fn.Text(); text != "" {
+		lines := strings.SplitN(text, "\n", 2)
+		op.Summary = lines[0]
+		if len(lines) > 1 {
+			op.Description = strings.TrimSpace(lines[1])
+		}
+	}
+	for _, a := range fn.Annotations() {
+		if a.Key == "tag" && a.Value != "" {
+			op.Tags = append(op.Tags, a.Value)
+		}
+	}
+	if len(op.Tags) == 0 {
+		op.Tags = []string{iface.Name}
+	}
+
+	var args []types.Variable
+	for _, a := range gen.Named(fn.Args, "arg", nil) {
+		if !gen.IsContext(a.Type) {
+			args = append(args, a)
+		}
+	}
+	inPath := make(map[string]bool)
+	for _, m := range pathParams.FindAllStringSubmatch(path, -1) {
+		inPath[m[1]] = true
+	}
+	var rest []types.Variable
+	for _, a := range args {
+		name := paramName(a.Name, inPath)
+		if name == "" {
+			rest = append(rest, a)
+			continue
+		}
+		schema, err := g.schema(a.Type, g.cfg.SourcePath, g.cfg.Resolver, 0)
+		if err != nil {
+			return "", "", nil, fmt.Errorf("%s: %v", a.Name, err)
+		}
+		op.Parameters = append(op.Parameters, &Parameter{Name: name, In: "path", Required: true, Schema: schema})
+		delete(inPath, name)
+	}
+	switch method {
+	case "get", "head", "delete":
+		for _, a := range rest {
+			params, err := g.queryParams(a, inPath)
+			if err != nil {
+				return "", "", nil, fmt.Errorf("%s: %v", a.Name, err)
+			}
+			op.Parameters = append(op.Parameters, params...)
+		}
+	default:
+		if op.RequestBody, err = g.body(rest); err != nil {
+			return "", "", nil, err
+		}
+	}
+	// Params of path, which are not arguments, are strings.
+	for _, m := range pathParams.FindAllStringSubmatch(path, -1) {
+		if inPath[m[1]] {
+			op.Parameters = append(op.Parameters, &Parameter{Name: m[1], In: "path", Required: true, Schema: &Schema{Type: "string"}})
+			delete(inPath, m[1])
+		}
+	}
+
+	results := fn.Results
+	hasError := false
+	if n := len(results); n > 0 && gen.IsError(results[n-1].Type) {
+		results, hasError = results[:n-1], true
+	}
+	code := "200"
+	if value, ok := fn.Annotation("success"); ok {
+		if _, err := strconv.Atoi(value); err != nil {
+			return "", "", nil, fmt.Errorf("invalid status code %q", value)
+		}
+		code = value
+	}
+	switch len(results) {
+	case 0:
+		if _, ok := fn.Annotation("success"); !ok {
+			code = "204"
+		}
+		op.Responses[code] = &Response{Description: "Success"}
+	default:
+		schema, err := g.object(gen.Named(results, "result", nil))
+		if err != nil {
+			return "", "", nil, err
+		}
+		op.Responses[code] = &Response{Description: "Success", Content: jsonContent(schema)}
+	}
+	if hasError {
+		op.Responses["default"] = &Response{Description: "Error"}
+	}
+	return method, path, op, nil
+}
+
+// Returns name of path parameter, which matches name of argument, or empty string.
+func paramName(arg string, inPath map[string]bool) string {
+	for param := range inPath {
+		if param == arg || strings.EqualFold(param, arg) || param == gen.Snake(arg) {
+			return param
+		}
+	}
+	return ""
+}
+
+// Returns query parameters of argument: fields of structure or the argument itself.
+// Fields, which are named as params of path, become path parameters.
+func (g *generator) queryParams(arg types.Variable, inPath map[string]bool) ([]*Parameter, error) {
+	t := arg.Type
+	if p, ok := t.(types.TPointer); ok {
+		t = p.Next
+	}
+	decl, path, scope := gen.Resolve(t, g.cfg.SourcePath, g.cfg.Resolver)
+	s, ok := decl.(*types.Struct)
+	if !ok {
+		schema, err := g.schema(arg.Type, g.cfg.SourcePath, g.cfg.Resolver, 0)
+		if err != nil {
+			return nil, err
+		}
+		_, pointer := arg.Type.(types.TPointer)
+		return []*Parameter{{Name: arg.Name, In: "query", Required: !pointer, Schema: schema}}, nil
+	}
+	var params []*Parameter
+	for _, f := range gen.EncodedFields(s, "json", path, scope) {
+		schema, err := g.schema(f.Type, f.Path, f.Scope, 0)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", f.Name, err)
+		}
+		p := &Parameter{Name: f.Key, In: "query", Description: f.Text(), Required: required(f), Schema: schema}
+		if inPath[f.Key] {
+			p.In, p.Required = "path", true
+			delete(inPath, f.Key)
+		}
+		if err := constrain(schema, f); err != nil {
+			return nil, fmt.Errorf("%s: %v", f.Name, err)
+		}
+		params = append(params, p)
+	}
+	return params, nil
+}
+
+func (g *generator) body(args []types.Variable) (*RequestBody, error) {
+	if len(args) == 0 {
+		return nil, nil
+	}
+	schema, err := g.object(args)
+	if err != nil {
+		return nil, err
+	}
+	return &RequestBody{Required: true, Content: jsonContent(schema)}, nil
+}
+
+// Returns schema of the only variable or of object with variables as properties.
+func (g *generator) object(vars []types.Variable) (*Schema, error) {
+	if len(vars) == 1 {
+		// Body is not nullable.
+		t := vars[0].Type
+		if p, ok := t.(types.TPointer); ok && p.NumberOfPointers == 1 {
+			t = p.Next
+		}
+		return g.schema(t, g.cfg.SourcePath, g.cfg.Resolver, 0)
+	}
+	object := &Schema{Type: "object"}
+	for _, v := range vars {
+		schema, err := g.schema(v.Type, g.cfg.SourcePath, g.cfg.Resolver, 0)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", v.Name, err)
+		}
+		object.Properties.Set(v.Name, schema)
+		if _, pointer := v.Type.(types.TPointer); !pointer {
+			object.Required = append(object.Required, v.Name)
+		}
+	}
+	return object, nil
+}
+
+func jsonContent(schema *Schema) map[string]*MediaType {
+	return map[string]*MediaType{"application/json": {Schema: schema}}
+}
+
+// Limits depth of chains of defined types.
+const maxDepth = 16
+
+var builtins = map[string]Schema{
+	"bool":    {Type: "boolean"},
+	"string":  {Type: "string"},
+	"int":     {Type: "integer", Format: "int64"},
+	"int64":   {Type: "integer", Format: "int64"},
+	"int32":   {Type: "integer", Format: "int32"},
+	"int16":   {Type: "integer", Format: "int32"},
+	"int8":    {Type: "integer", Format: "int32"},
+	"rune":    {Type: "integer", Format: "int32"},
+	"uint":    {Type: "integer", Format: "int64"},
+	"uint64":  {Type: "integer", Format: "int64"},
+	"uint32":  {Type: "integer", Format: "int32"},
+	"uint16":  {Type: "integer", Format: "int32"},
+	"uint8":   {Type: "integer", Format: "int32"},
+	"byte":    {Type: "integer", Format: "int32"},
+	"float64": {Type: "number", Format: "double"},
+	"float32": {Type: "number", Format: "float"},
+}
+
+// Returns schema of type from package path, which types are found by r.
+func (g *generator) schema(t types.Type, path string, r types.Resolver, depth int) (*Schema, error) {
+	if depth > maxDepth {
+		return nil, fmt.Errorf("too deep definition of type %s", printer.TypeString(t))
+	}
+	switch tt := t.(type) {
+	case types.TPointer:
+		s, err := g.schema(tt.Next, path, r, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		if s.Ref != "" {
+			return &Schema{AllOf: []*Schema{s}, Nullable: true}, nil
+		}
+		s.Nullable = true
+		return s, nil
+	case types.TArray:
+		if name, ok := tt.Next.(types.TName); ok && tt.IsSlice && (name.TypeName == "byte" || name.TypeName == "uint8") {
+			return &Schema{Type: "string", Format: "byte"}, nil
+		}
+		items, err := g.schema(tt.Next, path, r, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		s := &Schema{Type: "array", Items: items}
+		if !tt.IsSlice && !tt.IsEllipsis {
+			s.MinItems, s.MaxItems = intPtr(tt.ArrayLen), intPtr(tt.ArrayLen)
+		}
+		return s, nil
+	case types.TEllipsis:
+		items, err := g.schema(tt.Next, path, r, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Type: "array", Items: items}, nil
+	case types.TMap:
+		value, err := g.schema(tt.Value, path, r, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Type: "object", AdditionalProperties: value}, nil
+	case types.TInterface:
+		return &Schema{}, nil
+	case types.TName:
+		if s, ok := builtins[tt.TypeName]; ok {
+			return &s, nil
+		}
+		if types.IsBuiltinTypeString(tt.TypeName) {
+			return nil, fmt.Errorf("unsupported type %s", tt.TypeName)
+		}
+	case types.TImport:
+		if name, ok := tt.Next.(types.TName); ok && tt.Import != nil && tt.Import.Package == "time" {
+			switch name.TypeName {
+			case "Time":
+				return &Schema{Type: "string", Format: "date-time"}, nil
+			case "Duration":
+				return &Schema{Type: "integer", Format: "int64"}, nil
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unsupported type %s", printer.TypeString(t))
+	}
+	decl, declPath, scope := gen.Resolve(t, path, r)
+	switch d := decl.(type) {
+	case *types.Struct:
+		return g.structRef(d, declPath, scope)
+	case *types.FileType:
+		s, err := g.schema(d.Type, declPath, scope, depth+1)
+		if err == nil && s.Description == "" && s.Ref == "" {
+			s.Description = d.Text()
+		}
+		return s, err
+	case *types.Interface:
+		return &Schema{}, nil
+	}
+	return nil, fmt.Errorf("can't find type %s", printer.TypeString(t))
+}
+
+// Adds schema of structure to components and returns reference to it.
+func (g *generator) structRef(s *types.Struct, path string, r types.Resolver) (*Schema, error) {
+	ref := &Schema{Ref: "#/components/schemas/" + s.Name}
+	key := path + "." + s.Name
+	if other, ok := g.keys[s.Name]; ok {
+		if other != key {
+			return nil, fmt.Errorf("schema %s is declared by %s and %s", s.Name, other, key)
+		}
+		return ref, nil
+	}
+	g.keys[s.Name] = key
+	schema := &Schema{Type: "object", Description: s.Text()}
+	g.schemas[s.Name] = schema
+	for _, f := range gen.EncodedFields(s, "json", path, r) {
+		fs, err := g.schema(f.Type, f.Path, f.Scope, 0)
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %v", s.Name, f.Name, err)
+		}
+		if f.Quoted && (fs.Type == "integer" || fs.Type == "number" || fs.Type == "boolean") {
+			fs.Type = "string"
+		}
+		if err := constrain(fs, f); err != nil {
+			return nil, fmt.Errorf("%s.%s: %v", s.Name, f.Name, err)
+		}
+		if text := f.Text(); text != "" {
+			fs = describe(fs, text)
+		}
+		schema.Properties.Set(f.Key, fs)
+		if required(f) {
+			schema.Required = append(schema.Required, f.Key)
+		}
+	}
+	return ref, nil
+}
+
+// Returns schema with description. Siblings of reference are ignored, so reference is wrapped by allOf.
+func describe(s *Schema, text string) *Schema {
+	if s.Ref != "" {
+		return &Schema{AllOf: []*Schema{s}, Description: text}
+	}
+	s.Description = text
+	return s
+}
+
+// Field is required, when it is not omitted and is not a pointer, or when it is validated as required.
+func required(f gen.Field) bool {
+	for _, rule := range f.Tags["validate"] {
+		if rule == "required" {
+			return true
+		}
+	}
+	_, pointer := f.Type.(types.TPointer)
+	return !f.OmitEmpty && !pointer
+}
+
+var formats = map[string]string{"email": "email", "url": "uri", "uri": "uri", "uuid": "uuid", "ipv4": "ipv4", "ipv6": "ipv6"}
+
+// Sets constraints of schema from `validate` tag of field.
+func constrain(s *Schema, f gen.Field) error {
+	target := s
+	if len(s.AllOf) == 1 {
+		target = s.AllOf[0]
+	}
+	if target.Ref != "" {
+		return nil
+	}
+	for _, rule := range f.Tags["validate"] {
+		key, value := rule, ""
+		if i := strings.Index(rule, "="); i >= 0 {
+			key, value = rule[:i], rule[i+1:]
+		}
+		if format, ok := formats[key]; ok {
+			target.Format = format
+			continue
+		}
+		switch key {
+		case "min", "max", "len", "gt", "gte", "lt", "lte":
+			n, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return fmt.Errorf("invalid value of validation rule %q", rule)
+			}
+			bound(target, key, n)
+		case "oneof":
+			for _, v := range strings.Fields(value) {
+				target.Enum = append(target.Enum, enumValue(target.Type, v))
+			}
+		}
+	}
+	return nil
+}
+
+// Sets bound of length, number of items or value, depending on type of schema.
+func bound(s *Schema, rule string, n float64) {
+	lower := rule == "min" || rule == "gt" || rule == "gte" || rule == "len"
+	upper := rule == "max" || rule == "lt" || rule == "lte" || rule == "len"
+	switch s.Type {
+	case "string":
+		if lower {
+			s.MinLength = intPtr(int(n))
+		}
+		if upper {
+			s.MaxLength = intPtr(int(n))
+		}
+	case "array":
+		if lower {
+			s.MinItems = intPtr(int(n))
+		}
+		if upper {
+			s.MaxItems = intPtr(int(n))
+		}
+	case "integer", "number":
+		if lower {
+			s.Minimum, s.ExclusiveMinimum = &n, rule == "gt"
+		}
+		if upper {
+			s.Maximum, s.ExclusiveMaximum = &n, rule == "lt"
+		}
+	}
+}
+
+func enumValue(typ, v string) interface{} {
+	switch typ {
+	case "integer", "number":
+		if n, err := strconv.ParseFloat(v, 64); err == nil {
+			return n
+		}
+	}
+	return v
+}
+
+func intPtr(n int) *int {
+	return &n
+}
@@ -0,0 +1,519 @@
+// Package proto generates protobuf definitions of gRPC services from interfaces and messages from structures.
+//
+// Method of interface becomes rpc. Its request is the structure, which is the only argument except context,
+// and response is the structure, which is the only result except error.
+// Otherwise messages `<Service><Method>Request` and `<Service><Method>Response` are generated from arguments and results,
+// and methods without them use `google.protobuf.Empty`.
+//
+// Types are mapped to proto scalars, slices to `repeated` fields, maps to `map<K, V>`, pointers to `optional` fields,
+// `time.Time` and `time.Duration` to well-known types. Embedded structures are flattened.
+//
+// Name of field is snake case of its name in `json` tag or Go name. Tag `proto:"N"` sets number of field,
+// `proto:"N,name"` also its name, `proto:"-"` skips field. Other fields keep numbers from Lock,
+// new fields get numbers after the largest known one and numbers of removed fields are reserved.
+package proto
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/vetcher/godecl/gen"
+	"github.com/vetcher/godecl/printer"
+	"github.com/vetcher/godecl/types"
+)
+
+// Config describes generated file.
+type Config struct {
+	// Name of proto package.
+	Package string
+	// Value of option go_package, it is omitted when empty.
+	GoPackage string
+	// Import path of package, where interfaces and structures are declared.
+	SourcePath string
+	// Finds declarations of types of fields, may be nil.
+	Resolver types.Resolver
+	// Numbers of fields of previous generation, it is updated by Generate. May be nil.
+	Lock *Lock
+}
+
+// Lock keeps numbers of fields of messages between generations.
+type Lock struct {
+	// Numbers of fields by names of messages and fields. Removed fields are kept, so their numbers are not reused.
+	Messages map[string]map[string]int `json:"messages"`
+}
+
+// Reads lock from file. Returns empty lock, when file does not exist.
+func ReadLock(filename string) (*Lock, error) {
+	data, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return &Lock{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var l Lock
+	if err := json.Unmarshal(data, &l); err != nil {
+		return nil, fmt.Errorf("can't read lock %s: %v", filename, err)
+	}
+	return &l, nil
+}
+
+// Writes lock to file atomically.
+func (l *Lock) Write(filename string) error {
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+	return gen.WriteFile(filename, append(data, '\n'))
+}
+
+// Returns source of proto file with services of interfaces and messages of structures.
+func Generate(cfg Config, ifaces []*types.Interface, structs []*types.Struct) ([]byte, error) {
+	g := &generator{cfg: cfg, imports: make(map[string]bool), byKey: make(map[string]*message), byName: make(map[string]string)}
+	for _, iface := range ifaces {
+		if err := g.service(iface); err != nil {
+			return nil, err
+		}
+	}
+	for _, s := range structs {
+		if _, err := g.structure(s, cfg.SourcePath, cfg.Resolver); err != nil {
+			return nil, err
+		}
+	}
+	lock := cfg.Lock
+	if lock == nil {
+		lock = &Lock{}
+	}
+	for _, m := range g.messages {
+		if err := m.number(lock); err != nil {
+			return nil, err
+		}
+	}
+	return g.source(), nil
+}
+
+type generator struct {
+	cfg      Config
+	imports  map[string]bool
+	services []*service
+	messages []*message
+	byKey    map[string]*message
+	// Keys of messages by their names, to find conflicts of structures of different packages.
+	byName map[string]string
+}
+
+type service struct {
+	name, docs string
+	rpcs       []rpc
+}
+
+type rpc struct {
+	name, docs        string
+	request, response string
+}
+
+type message struct {
+	name, docs string
+	fields     []*field
+	reserved   []int
+}
+
+type field struct {
+	name, docs string
+	// Label is `repeated`, `optional` or empty.
+	label, typ string
+	// Number, set by tag, or 0.
+	tagged int
+	number int
+}
+
+func (g *generator) service(iface *types.Interface) error {
+	s := &service{name: iface.Name, docs: iface.Text()}
+	for _, fn := range iface.AllMethods(g.cfg.Resolver) {
+		var args, results []types.Variable
+		for _, a := range fn.Args {
+			if !gen.IsContext(a.Type) {
+				args = append(args, a)
+			}
+		}
+		results = fn.Results
+		if n := len(results); n > 0 && gen.IsError(results[n-1].Type) {
+			results = results[:n-1]
+		}
+		request, err := g.params(iface.Name+fn.Name+"Request", args)
+		if err != nil {
+			return fmt.Errorf("%s.%s: %v", iface.Name, fn.Name, err)
+		}
+		response, err := g.params(iface.Name+fn.Name+"Response", results)
+		if err != nil {
+			return fmt.Errorf("%s.%s: %v", iface.Name, fn.Name, err)
+		}
+		s.rpcs = append(s.rpcs, rpc{name: fn.Name, docs: fn.Text(), request: request, response: response})
+	}
+	g.services = append(g.services, s)
+	return nil
+}
+
+// Returns message of params: the only structure, Empty or message name, generated from params.
+func (g *generator) params(name string, vars []types.Variable) (string, error) {
+	switch len(vars) {
+	case 0:
+		g.imports["google/protobuf/empty.proto"] = true
+		return "google.protobuf.Empty", nil
+	case 1:
+		t := vars[0].Type
+		if p, ok := t.(types.TPointer); ok && p.NumberOfPointers == 1 {
+			t = p.Next
+		}
+		if s, path, r := g.resolveStruct(t, g.cfg.SourcePath, g.cfg.Resolver); s != nil {
+			return g.structure(s, path, r)
+		}
+	}
+	// Generated messages have no package, so structure with the same name is reported by add.
+	m, err := g.add(name, "", "")
+	if err != nil || m == nil {
+		return name, err
+	}
+	for _, v := range gen.Named(vars, "arg", nil) {
+		f, err := g.field(gen.Snake(v.Name), v.Type, g.cfg.SourcePath, g.cfg.Resolver)
+		if err != nil {
+			return "", fmt.Errorf("%s: %v", v.Name, err)
+		}
+		m.fields = append(m.fields, f)
+	}
+	return name, nil
+}
+
+// Adds message, returns nil message, when it is already added.
+func (g *generator) add(name, path, docs string) (*message, error) {
+	key := path + "." + name
+	if _, ok := g.byKey[key]; ok {
+		return nil, nil
+	}
+	if other, ok := g.byName[name]; ok {
+		return nil, fmt.Errorf("message %s is declared by %s and %s", name, other, key)
+	}
+	m := &message{name: name, docs: docs}
+	g.byKey[key], g.byName[name] = m, key
+	g.messages = append(g.messages, m)
+	return m, nil
+}
+
+// Adds message of structure from package path, which types are found by r.
+func (g *generator) structure(s *types.Struct, path string, r types.Resolver) (string, error) {
+	m, err := g.add(s.Name, path, s.Text())
+	if err != nil || m == nil {
+		return s.Name, err
+	}
+	if err := g.fields(m, s, path, r); err != nil {
+		return "", fmt.Errorf("%s: %v", s.Name, err)
+	}
+	return s.Name, nil
+}
+
+func (g *generator) fields(m *message, s *types.Struct, path string, r types.Resolver) error {
+	for _, sf := range s.Fields {
+		tag := sf.Tags["proto"]
+		if len(tag) > 0 && tag[0] == "-" {
+			continue
+		}
+		if sf.Name == "" {
+			t := sf.Type
+			if p, ok := t.(types.TPointer); ok {
+				t = p.Next
+			}
+			embedded, embeddedPath, embeddedScope := g.resolveStruct(t, path, r)
+			if embedded == nil {
+				return fmt.Errorf("can't find embedded structure %s", printer.TypeString(sf.Type))
+			}
+			if err := g.fields(m, embedded, embeddedPath, embeddedScope); err != nil {
+				return err
+			}
+			continue
+		}
+		if !ast.IsExported(sf.Name) {
+			continue
+		}
+		name := sf.Name
+		if json := sf.Tags["json"]; len(json) > 0 && json[0] != "" && json[0] != "-" {
+			name = json[0]
+		}
+		name = gen.Snake(name)
+		if len(tag) > 1 && tag[1] != "" {
+			name = tag[1]
+		}
+		f, err := g.field(name, sf.Type, path, r)
+		if err != nil {
+			return fmt.Errorf("%s: %v", sf.Name, err)
+		}
+		f.docs = sf.Text()
+		if len(tag) > 0 && tag[0] != "" {
+			if f.tagged, err = strconv.Atoi(tag[0]); err != nil || f.tagged <= 0 {
+				return fmt.Errorf("%s: invalid number of field %q", sf.Name, tag[0])
+			}
+		}
+		m.fields = append(m.fields, f)
+	}
+	return nil
+}
+
+func (g *generator) field(name string, t types.Type, path string, r types.Resolver) (*field, error) {
+	label, typ, err := g.fieldType(t, path, r, 0)
+	if err != nil {
+		return nil, err
+	}
+	return &field{name: name, label: label, typ: typ}, nil
+}
+
+// Limits depth of chains of defined types.
+const maxDepth = 16
+
+var scalars = map[string]string{
+	"bool": "bool", "string": "string",
+	"int": "int64", "int64": "int64", "int32": "int32", "int16": "int32", "int8": "int32", "rune": "int32",
+	"uint": "uint64", "uint64": "uint64", "uint32": "uint32", "uint16": "uint32", "uint8": "uint32", "byte": "uint32",
+	"float64": "double", "float32": "float",
+}
+
+// Returns label and proto type of Go type from package path, which types are found by r.
+func (g *generator) fieldType(t types.Type, path string, r types.Resolver, depth int) (label, typ string, err error) {
+	if depth > maxDepth {
+		return "", "", fmt.Errorf("too deep definition of type %s", printer.TypeString(t))
+	}
+	switch tt := t.(type) {
+	case types.TPointer:
+		label, typ, err = g.fieldType(tt.Next, path, r, depth+1)
+		if err == nil && label == "" && tt.NumberOfPointers == 1 {
+			label = "optional"
+		}
+		return label, typ, err
+	case types.TArray, types.TEllipsis:
+		var elem types.Type
+		if a, ok := tt.(types.TArray); ok {
+			elem = a.Next
+		} else {
+			elem = tt.(types.TEllipsis).Next
+		}
+		if name, ok := elem.(types.TName); ok && (name.TypeName == "byte" || name.TypeName == "uint8") {
+			return "", "bytes", nil
+		}
+		label, typ, err = g.fieldType(elem, path, r, depth+1)
+		if err != nil {
+			return "", "", err
+		}
+		if label == "repeated" || strings.HasPrefix(typ, "map<") {
+			return "", "", fmt.Errorf("repeated fields can't be nested: %s", printer.TypeString(t))
+		}
+		return "repeated", typ, nil
+	case types.TMap:
+		keyLabel, key, err := g.fieldType(tt.Key, path, r, depth+1)
+		if err != nil {
+			return "", "", err
+		}
+		if keyLabel != "" || key == "bytes" || key == "float" || key == "double" || !isScalar(key) {
+			return "", "", fmt.Errorf("unsupported type of map key: %s", printer.TypeString(tt.Key))
+		}
+		valueLabel, value, err := g.fieldType(tt.Value, path, r, depth+1)
+		if err != nil {
+			return "", "", err
+		}
+		if valueLabel == "repeated" || strings.HasPrefix(value, "map<") {
+			return "", "", fmt.Errorf("unsupported type of map value: %s", printer.TypeString(tt.Value))
+		}
+		return "", "map<" + key + ", " + value + ">", nil
+	case types.TName:
+		if scalar, ok := scalars[tt.TypeName]; ok {
+			return "", scalar, nil
+		}
+		if types.IsBuiltinTypeString(tt.TypeName) {
+			return "", "", fmt.Errorf("unsupported type %s", tt.TypeName)
+		}
+	case types.TImport:
+		if name, ok := tt.Next.(types.TName); ok && tt.Import != nil && tt.Import.Package == "time" {
+			switch name.TypeName {
+			case "Time":
+				g.imports["google/protobuf/timestamp.proto"] = true
+				return "", "google.protobuf.Timestamp", nil
+			case "Duration":
+				g.imports["google/protobuf/duration.proto"] = true
+				return "", "google.protobuf.Duration", nil
+			}
+		}
+	default:
+		return "", "", fmt.Errorf("unsupported type %s", printer.TypeString(t))
+	}
+	if r == nil {
+		return "", "", fmt.Errorf("can't find type %s", printer.TypeString(t))
+	}
+	decl, scope := r.Resolve(t)
+	declPath := path
+	if imp, ok := t.(types.TImport); ok && imp.Import != nil {
+		declPath = imp.Import.Package
+	}
+	switch d := decl.(type) {
+	case *types.Struct:
+		name, err := g.structure(d, declPath, scope)
+		return "", name, err
+	case *types.FileType:
+		return g.fieldType(d.Type, declPath, scope, depth+1)
+	case nil:
+		return "", "", fmt.Errorf("can't find type %s", printer.TypeString(t))
+	}
+	return "", "", fmt.Errorf("unsupported type %s", printer.TypeString(t))
+}
+
+func isScalar(typ string) bool {
+	for _, scalar := range scalars {
+		if scalar == typ {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns structure, which is type t, its package path and resolver of its package.
+func (g *generator) resolveStruct(t types.Type, path string, r types.Resolver) (*types.Struct, string, types.Resolver) {
+	decl, path, scope := gen.Resolve(t, path, r)
+	s, ok := decl.(*types.Struct)
+	if !ok {
+		return nil, "", nil
+	}
+	return s, path, scope
+}
+
+// Reserved by protobuf implementation.
+const firstReserved, lastReserved = 19000, 19999
+
+// Sets numbers of fields: numbers from tags, then numbers from lock, then numbers after the largest known one.
+// Updates lock with numbers of fields.
+func (m *message) number(lock *Lock) error {
+	if lock.Messages == nil {
+		lock.Messages = make(map[string]map[string]int)
+	}
+	locked := lock.Messages[m.name]
+	if locked == nil {
+		locked = make(map[string]int)
+		lock.Messages[m.name] = locked
+	}
+	used := make(map[int]string)
+	names := make(map[string]bool)
+	max := 0
+	for _, f := range m.fields {
+		if names[f.name] {
+			return fmt.Errorf("%s: field %s is declared twice", m.name, f.name)
+		}
+		names[f.name] = true
+		if f.tagged == 0 {
+			continue
+		}
+		if other, ok := used[f.tagged]; ok {
+			return fmt.Errorf("%s: fields %s and %s have the same number %d", m.name, other, f.name, f.tagged)
+		}
+		f.number, used[f.tagged] = f.tagged, f.name
+	}
+	for _, n := range locked {
+		if n > max {
+			max = n
+		}
+	}
+	for _, f := range m.fields {
+		if f.tagged > max {
+			max = f.tagged
+		}
+	}
+	for _, f := range m.fields {
+		if f.number != 0 {
+			continue
+		}
+		if n, ok := locked[f.name]; ok {
+			if _, taken := used[n]; !taken {
+				f.number, used[n] = n, f.name
+				continue
+			}
+		}
+		max++
+		if max >= firstReserved && max <= lastReserved {
+			max = lastReserved + 1
+		}
+		f.number, used[max] = max, f.name
+	}
+	for name, n := range locked {
+		if _, ok := used[n]; !ok && !names[name] {
+			m.reserved = append(m.reserved, n)
+		}
+	}
+	sort.Ints(m.reserved)
+	for _, f := range m.fields {
+		locked[f.name] = f.number
+	}
+	return nil
+}
+
+func (g *generator) source() []byte {
+	var b bytes.Buffer
+	b.WriteString(gen.Header + "\n\n")
+	b.WriteString("syntax = \"proto3\";\n\n")
+	if g.cfg.Package != "" {
+		fmt.Fprintf(&b, "package %s;\n\n", g.cfg.Package)
+	}
+	if len(g.imports) > 0 {
+		var imports []string
+		for imp := range g.imports {
+			imports = append(imports, imp)
+		}
+		sort.Strings(imports)
+		for _, imp := range imports {
+			fmt.Fprintf(&b, "import %q;\n", imp)
+		}
+		b.WriteString("\n")
+	}
+	if g.cfg.GoPackage != "" {
+		fmt.Fprintf(&b, "option go_package = %q;\n\n", g.cfg.GoPackage)
+	}
+	for _, s := range g.services {
+		writeDocs(&b, "", s.docs)
+		fmt.Fprintf(&b, "service %s {\n", s.name)
+		for _, r := range s.rpcs {
+			writeDocs(&b, "  ", r.docs)
+			fmt.Fprintf(&b, "  rpc %s(%s) returns (%s);\n", r.name, r.request, r.response)
+		}
+		b.WriteString("}\n\n")
+	}
+	for _, m := range g.messages {
+		writeDocs(&b, "", m.docs)
+		fmt.Fprintf(&b, "message %s {\n", m.name)
+		for _, f := range m.fields {
+			writeDocs(&b, "  ", f.docs)
+			b.WriteString("  ")
+			if f.label != "" {
+				b.WriteString(f.label + " ")
+			}
+			fmt.Fprintf(&b, "%s %s = %d;\n", f.typ, f.name, f.number)
+		}
+		if len(m.reserved) > 0 {
+			numbers := make([]string, len(m.reserved))
+			for i, n := range m.reserved {
+				numbers[i] = strconv.Itoa(n)
+			}
+			fmt.Fprintf(&b, "  reserved %s;\n", strings.Join(numbers, ", "))
+		}
+		b.WriteString("}\n\n")
+	}
+	return append(bytes.TrimRight(b.Bytes(), "\n"), '\n')
+}
+
+func writeDocs(b *bytes.Buffer, indent, docs string) {
+	if docs == "" {
+		return
+	}
+	for _, line := range strings.Split(docs, "\n") {
+		b.WriteString(strings.TrimRight(indent+"// "+line, " ") + "\n")
+	}
+}
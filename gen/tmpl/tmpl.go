@@ -0,0 +1,198 @@
+// Package tmpl generates Go files by text/template over declarations model.
+//
+// Template renders body of file: header, package clause and imports are added by generator,
+// imports are planned by helpers `type` and `import`. Template is executed with Data as dot.
+//
+// Helpers:
+//
+//	type T               type T, qualified by imports of generated file
+//	import "path"        adds import and returns its name
+//	params VARS          params in form `a int, b ...string`
+//	results VARS         results of signature: nothing, ` T` or ` (a T, err error)`
+//	args VARS            names of params for call, variadic one is followed by `...`
+//	named VARS "p"       params, where unnamed and conflicting ones are named by prefix, e.g. `p0`
+//	isExported NAME      reports whether name is exported
+//	isUnexported NAME    reports whether name is unexported
+//	exported NAME        `userID` -> `UserID`
+//	unexported NAME      `HTTPClient` -> `httpClient`
+//	camel NAME           `user_id` -> `userID`
+//	pascal NAME          `user_id` -> `UserID`
+//	snake NAME           `UserID` -> `user_id`
+//	kebab NAME           `UserID` -> `user-id`
+//	lower, upper, join   functions of package strings
+//	tag FIELD "json"     first value of tag, usually name, or empty string
+//	tagOptions FIELD "json"  other values of tag, e.g. `omitempty`
+//	hasTag FIELD "json"  reports whether field has tag
+//	annotation X "key"   value of annotation `// @key value` of declaration or empty string
+//	hasAnnotation X "key"
+//	interfaces PKG       interfaces of all files of package, also structs, functions, types, constants and vars
+//	methods IFACE        methods of interface, including methods of embedded interfaces
+//	isContext T          reports whether type is context.Context
+//	isError T            reports whether type is error
+//	isVariadic VAR       reports whether param is variadic
+package tmpl
+
+import (
+	"go/ast"
+	"strings"
+	"text/template"
+
+	"github.com/vetcher/godecl/gen"
+	"github.com/vetcher/godecl/types"
+)
+
+// Config describes generated file.
+type Config struct {
+	// Name and import path of package of generated file.
+	Package     string
+	PackagePath string
+	// Import path of package, where unqualified types of model are declared.
+	SourcePath string
+	// Finds embedded interfaces, may be nil.
+	Resolver types.Resolver
+}
+
+// Data is a dot of executed template.
+type Data struct {
+	// The first of packages.
+	Package  *types.Package
+	Packages []*types.Package
+	// Name of package of generated file.
+	Output string
+}
+
+// Parses template text with helpers of file.
+func Parse(f *gen.File, r types.Resolver, name, text string) (*template.Template, error) {
+	return template.New(name).Funcs(Funcs(f, r)).Parse(text)
+}
+
+// Returns source of Go file, which body is rendered by template text.
+func Generate(cfg Config, name, text string, pkgs ...*types.Package) ([]byte, error) {
+	f := gen.NewFile(cfg.Package, cfg.PackagePath, cfg.SourcePath)
+	t, err := Parse(f, cfg.Resolver, name, text)
+	if err != nil {
+		return nil, err
+	}
+	data := Data{Packages: pkgs, Output: cfg.Package}
+	if len(pkgs) > 0 {
+		data.Package = pkgs[0]
+	}
+	if err := t.Execute(f, data); err != nil {
+		return nil, err
+	}
+	return f.Source()
+}
+
+// Declarations, which have doc comments.
+type annotated interface {
+	Annotation(key string) (string, bool)
+}
+
+// Returns helpers, which qualify types by imports of file and find embedded interfaces by resolver.
+func Funcs(f *gen.File, r types.Resolver) template.FuncMap {
+	return template.FuncMap{
+		"type":    f.Type,
+		"import":  f.Import,
+		"params":  f.Params,
+		"results": f.Results,
+		"args":    gen.Args,
+		"named": func(vars []types.Variable, prefix string) []types.Variable {
+			return gen.Named(vars, prefix, nil)
+		},
+		"isExported":   ast.IsExported,
+		"isUnexported": func(name string) bool { return !ast.IsExported(name) },
+		"exported":     gen.Exported,
+		"unexported":   gen.Unexported,
+		"camel":        func(name string) string { return gen.Unexported(pascal(name)) },
+		"pascal":       pascal,
+		"snake":        gen.Snake,
+		"kebab":        func(name string) string { return strings.Join(gen.Words(name), "-") },
+		"lower":        strings.ToLower,
+		"upper":        strings.ToUpper,
+		"join":         strings.Join,
+		"tag": func(field types.StructField, key string) string {
+			if values := field.Tags[key]; len(values) > 0 {
+				return values[0]
+			}
+			return ""
+		},
+		"tagOptions": func(field types.StructField, key string) []string {
+			if values := field.Tags[key]; len(values) > 1 {
+				return values[1:]
+			}
+			return nil
+		},
+		"hasTag": func(field types.StructField, key string) bool {
+			_, ok := field.Tags[key]
+			return ok
+		},
+		"annotation": func(x annotated, key string) string {
+			value, _ := x.Annotation(key)
+			return value
+		},
+		"hasAnnotation": func(x annotated, key string) bool {
+			_, ok := x.Annotation(key)
+			return ok
+		},
+		"interfaces": func(pkg *types.Package) (decls []types.Interface) {
+			for _, file := range pkg.Files {
+				decls = append(decls, file.Interfaces...)
+			}
+			return
+		},
+		"structs": func(pkg *types.Package) (decls []types.Struct) {
+			for _, file := range pkg.Files {
+				decls = append(decls, file.Structures...)
+			}
+			return
+		},
+		"functions": func(pkg *types.Package) (decls []types.Function) {
+			for _, file := range pkg.Files {
+				decls = append(decls, file.Functions...)
+			}
+			return
+		},
+		"types": func(pkg *types.Package) (decls []types.FileType) {
+			for _, file := range pkg.Files {
+				decls = append(decls, file.Types...)
+			}
+			return
+		},
+		"constants": func(pkg *types.Package) (decls []types.Variable) {
+			for _, file := range pkg.Files {
+				decls = append(decls, file.Constants...)
+			}
+			return
+		},
+		"vars": func(pkg *types.Package) (decls []types.Variable) {
+			for _, file := range pkg.Files {
+				decls = append(decls, file.Vars...)
+			}
+			return
+		},
+		"methods": func(iface types.Interface) []*types.Function {
+			return iface.AllMethods(r)
+		},
+		"isContext":  gen.IsContext,
+		"isError":    gen.IsError,
+		"isVariadic": gen.IsVariadic,
+	}
+}
+
+// Common initialisms, which are written in upper case, when word is not the first one of camel case name.
+var initialisms = map[string]bool{
+	"api": true, "http": true, "https": true, "id": true, "ip": true, "json": true, "sql": true,
+	"tcp": true, "udp": true, "ui": true, "uid": true, "uri": true, "url": true, "uuid": true, "xml": true, "yaml": true,
+}
+
+func pascal(name string) string {
+	var b strings.Builder
+	for _, w := range gen.Words(name) {
+		if initialisms[w] {
+			b.WriteString(strings.ToUpper(w))
+		} else {
+			b.WriteString(gen.Exported(w))
+		}
+	}
+	return b.String()
+}
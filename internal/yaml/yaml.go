@@ -0,0 +1,134 @@
+// Package yaml encodes JSON-compatible values as YAML documents in block style.
+package yaml
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Returns YAML of v. Value is encoded to JSON first, so json tags and MarshalJSON methods are respected.
+func Marshal(v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return FromJSON(data)
+}
+
+// Converts JSON document to YAML. Order of object keys is preserved.
+func FromJSON(data []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	n, err := decode(dec)
+	if err != nil {
+		return nil, fmt.Errorf("can't decode json: %v", err)
+	}
+	var buf bytes.Buffer
+	if n.inline() {
+		buf.WriteString(n.scalar + "\n")
+	} else {
+		n.write(&buf, 0)
+	}
+	return buf.Bytes(), nil
+}
+
+type node struct {
+	scalar string
+	keys   []string // Keys of object.
+	values []*node  // Values of object or items of array.
+	object bool
+}
+
+// Scalars and empty collections are written in the same line as their keys.
+func (n *node) inline() bool {
+	return len(n.values) == 0
+}
+
+func decode(dec *json.Decoder) (*node, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch t := tok.(type) {
+	case json.Delim:
+		n := &node{object: t == '{', scalar: "[]"}
+		if n.object {
+			n.scalar = "{}"
+		}
+		for dec.More() {
+			if n.object {
+				key, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				n.keys = append(n.keys, quote(key.(string)))
+			}
+			value, err := decode(dec)
+			if err != nil {
+				return nil, err
+			}
+			n.values = append(n.values, value)
+		}
+		// Closing delimiter.
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		return n, nil
+	case string:
+		return &node{scalar: quote(t)}, nil
+	case json.Number:
+		return &node{scalar: t.String()}, nil
+	case bool:
+		return &node{scalar: strconv.FormatBool(t)}, nil
+	case nil:
+		return &node{scalar: "null"}, nil
+	}
+	return nil, fmt.Errorf("unexpected token %v", tok)
+}
+
+func (n *node) write(buf *bytes.Buffer, indent int) {
+	pad := strings.Repeat(" ", indent)
+	for i, value := range n.values {
+		if n.object {
+			buf.WriteString(pad + n.keys[i] + ":")
+		} else {
+			buf.WriteString(pad + "-")
+		}
+		switch {
+		case value.inline():
+			buf.WriteString(" " + value.scalar + "\n")
+		case n.object && value.object:
+			buf.WriteString("\n")
+			value.write(buf, indent+2)
+		case n.object:
+			// Items of array are not indented relative to their key.
+			buf.WriteString("\n")
+			value.write(buf, indent)
+		default:
+			// First entry of nested collection starts in the line of item.
+			var nested bytes.Buffer
+			value.write(&nested, indent+2)
+			buf.WriteString(" ")
+			buf.Write(nested.Bytes()[indent+2:])
+		}
+	}
+}
+
+var plain = regexp.MustCompile(`^[A-Za-z_/][A-Za-z0-9_ ./()-]*$`)
+
+// Words, which are not strings in YAML 1.1 or 1.2, when they are not quoted.
+var reserved = map[string]bool{
+	"y": true, "n": true, "yes": true, "no": true, "on": true, "off": true,
+	"true": true, "false": true, "null": true,
+}
+
+func quote(s string) string {
+	if plain.MatchString(s) && !strings.HasSuffix(s, " ") && !reserved[strings.ToLower(s)] {
+		return s
+	}
+	return strconv.Quote(s)
+}
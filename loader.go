@@ -0,0 +1,230 @@
+package godecl
+
+import (
+	"fmt"
+	"go/build"
+	astparser "go/parser"
+	"go/token"
+	"path/filepath"
+
+	"github.com/vetcher/godecl/types"
+)
+
+// Loader parses packages by import paths and caches them.
+// It implements types.Importer, so packages from other modules can be found by resolvers.
+type Loader struct {
+	// Context is used to find and filter package files. When nil, build.Default is used.
+	Context *build.Context
+	// Directory, relative to which import paths are resolved, e.g. for vendor directories.
+	Dir string
+	// Include test files of packages, loaded by LoadDir, both in-package and external tests.
+	Tests bool
+	// Skip declarations and files, which can't be parsed, instead of failing.
+	// Errors of skipped declarations are collected in Errors.
+	Lenient bool
+	Errors  []error
+
+	packages map[string]*types.Package
+	errors   map[string]error
+}
+
+// Returns loader with default build context.
+func NewLoader() *Loader {
+	return &Loader{}
+}
+
+func (l *Loader) context() *build.Context {
+	if l.Context != nil {
+		return l.Context
+	}
+	return &build.Default
+}
+
+// Returns parsed package by import path.
+func (l *Loader) Import(path string) (*types.Package, error) {
+	if pkg, ok := l.packages[path]; ok {
+		return pkg, nil
+	}
+	if err, ok := l.errors[path]; ok {
+		return nil, err
+	}
+	bp, err := l.context().Import(path, l.Dir, 0)
+	pkg, err := l.load(bp, err, false)
+	l.remember(path, pkg, err)
+	return pkg, err
+}
+
+// Returns parsed package in directory dir.
+func (l *Loader) LoadDir(dir string) (*types.Package, error) {
+	bp, err := l.context().ImportDir(dir, 0)
+	pkg, err := l.load(bp, err, l.Tests)
+	if err == nil && pkg.Path != "" && !l.Tests {
+		l.remember(pkg.Path, pkg, nil)
+	}
+	return pkg, err
+}
+
+func (l *Loader) remember(path string, pkg *types.Package, err error) {
+	if l.packages == nil {
+		l.packages = make(map[string]*types.Package)
+		l.errors = make(map[string]error)
+	}
+	if err != nil {
+		l.errors[path] = err
+		return
+	}
+	l.packages[path] = pkg
+}
+
+// Returns package of files, which are parsed as files of one package without build constraints.
+func (l *Loader) LoadFiles(filenames ...string) (*types.Package, error) {
+	if len(filenames) == 0 {
+		return nil, fmt.Errorf("no files to load")
+	}
+	dir := filepath.Dir(filenames[0])
+	names := make([]string, len(filenames))
+	for i, filename := range filenames {
+		if filepath.Dir(filename) != dir {
+			return nil, fmt.Errorf("files %s and %s are in different directories", filenames[0], filename)
+		}
+		names[i] = filepath.Base(filename)
+	}
+	return l.parse(dir, names, packagePathOf(dir), "")
+}
+
+func (l *Loader) load(bp *build.Package, err error, tests bool) (*types.Package, error) {
+	if err != nil {
+		return nil, fmt.Errorf("can not import package: %v", err)
+	}
+	pp := bp.ImportPath
+	if pp == "." || pp == "" {
+		pp = packagePathOf(bp.Dir)
+	}
+	names := append(bp.GoFiles, bp.CgoFiles...)
+	if tests {
+		names = append(append(names, bp.TestGoFiles...), bp.XTestGoFiles...)
+	}
+	return l.parse(bp.Dir, names, pp, bp.Name)
+}
+
+func (l *Loader) parse(dir string, names []string, packagePath, name string) (*types.Package, error) {
+	var files []*types.File
+	for _, n := range names {
+		filename := filepath.Join(dir, n)
+		if !l.Lenient {
+			file, err := parseFile(filename, packagePath)
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, file)
+			continue
+		}
+		file, errs := parseFileLenient(filename, packagePath)
+		l.Errors = append(l.Errors, errs...)
+		if file != nil {
+			files = append(files, file)
+		}
+	}
+	if name == "" && len(files) > 0 {
+		name = files[0].Name
+	}
+	l.resolveDotImports(files)
+	linkPackageMethods(files)
+	return &types.Package{Base: types.Base{Name: name}, Path: packagePath, Files: files}, nil
+}
+
+// Qualifies unqualified types, which are declared in dot-imported packages, by their imports.
+// Packages, which can't be loaded, are skipped.
+func (l *Loader) resolveDotImports(files []*types.File) {
+	local := make(map[string]bool)
+	for _, f := range files {
+		for _, s := range f.Structures {
+			local[s.Name] = true
+		}
+		for _, i := range f.Interfaces {
+			local[i.Name] = true
+		}
+		for _, t := range f.Types {
+			local[t.Name] = true
+		}
+	}
+	for i, f := range files {
+		var (
+			dots    []*types.Package
+			imports []*types.Import
+		)
+		for j := range f.Imports {
+			if !f.Imports[j].Dot {
+				continue
+			}
+			if pkg, err := l.Import(f.Imports[j].Package); err == nil {
+				dots = append(dots, pkg)
+				imports = append(imports, &f.Imports[j])
+			}
+		}
+		if len(dots) == 0 {
+			continue
+		}
+		files[i] = types.RewriteFile(f, func(t types.Type) (types.Type, bool) {
+			switch tt := t.(type) {
+			case types.TName:
+				if local[tt.TypeName] || types.IsBuiltinTypeString(tt.TypeName) {
+					return nil, false
+				}
+				for k, pkg := range dots {
+					if decl, _ := pkg.Scope(nil).Resolve(tt); decl != nil {
+						return types.TImport{Import: imports[k], Next: tt}, true
+					}
+				}
+			case types.TImport:
+				// Types of qualified type belong to its package.
+				return tt, true
+			}
+			return nil, false
+		})
+	}
+}
+
+// Parses file, skipping declarations, which can't be parsed, and returns their errors.
+// Returns nil file, when it can't be parsed at all.
+func parseFileLenient(filename, packagePath string) (*types.File, []error) {
+	fset := token.NewFileSet()
+	tree, err := astparser.ParseFile(fset, filename, nil, astparser.ParseComments|astparser.AllErrors)
+	if tree == nil {
+		return nil, []error{fmt.Errorf("error when parse file: %v", err)}
+	}
+	var errs []error
+	if err != nil {
+		errs = append(errs, err)
+	}
+	pp := packageImport(tree, packagePath)
+	// Declarations are checked one by one on a scratch file, which collects imports.
+	scratch := &types.File{}
+	lenient := *tree
+	lenient.Decls = nil
+	for _, decl := range tree.Decls {
+		if err := parseDeclaration(decl, scratch, pp, filepath.Dir(filename)); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %v", fset.Position(decl.Pos()), err))
+			continue
+		}
+		lenient.Decls = append(lenient.Decls, decl)
+	}
+	file, err := parseAstFile(&lenient, packagePath, filepath.Dir(filename))
+	if err != nil {
+		return nil, append(errs, fmt.Errorf("error when parsing info from file %s: %v", filename, err))
+	}
+	return file, errs
+}
+
+// Parses files of one package. Methods are not linked with declarations from other files.
+func parseFiles(dir string, names []string, packagePath string) ([]*types.File, error) {
+	var files []*types.File
+	for _, name := range names {
+		file, err := parseFile(filepath.Join(dir, name), packagePath)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
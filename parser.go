@@ -1,11 +1,14 @@
 package godecl
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"go/ast"
+	"go/constant"
+	astparser "go/parser"
+	"go/printer"
 	"go/token"
-	"path"
 	"strconv"
 	"strings"
 
@@ -15,7 +18,7 @@ import (
 
 var (
 	ErrCouldNotResolvePackage = errors.New("could not resolve package")
-	ErrUnexpectedSpec         = errors.New("unexpected spec")
+	ErrUnexpectedSpec         = types.ErrUnexpectedSpec
 	ErrNotInGoPath            = errors.New("not in GOPATH")
 	ErrGoPathIsEmpty          = errors.New("GOPATH is empty")
 )
@@ -23,25 +26,22 @@ var (
 // Parses ast.File and return all top-level declarations.
 // Deprecated: use https://github.com/Vetcher/go-astra instead.
 func ParseAstFile(file *ast.File, packagePath string) (*types.File, error) {
+	return parseAstFile(file, packagePath, "")
+}
+
+// Names of imported packages are resolved relative to srcDir, which may be empty.
+func parseAstFile(file *ast.File, packagePath, srcDir string) (*types.File, error) {
 	f := &types.File{
 		Base: types.Base{
 			Name: file.Name.Name,
 			Docs: parseComments(file.Doc),
 		},
 	}
-	var pp *types.Import
-	if packagePath != "" {
-		alias := constructAliasNameString(packagePath)
-		imp := types.Import{
-			Base: types.Base{
-				Name: alias,
-			},
-			Package: strings.Trim(packagePath, `"`),
-		}
-		f.Imports = append(f.Imports, imp)
-		pp = &imp
+	pp := packageImport(file, packagePath)
+	if pp != nil {
+		f.Imports = append(f.Imports, *pp)
 	}
-	err := parseTopLevelDeclarations(file.Decls, f, pp)
+	err := parseTopLevelDeclarations(file.Decls, f, pp, srcDir)
 	if err != nil {
 		return nil, err
 	}
@@ -66,6 +66,19 @@ func ParseAstFile(file *ast.File, packagePath string) (*types.File, error) {
 	return f, nil
 }
 
+// Returns import of the package of file itself or nil, when package path is unknown.
+func packageImport(file *ast.File, packagePath string) *types.Import {
+	if packagePath == "" {
+		return nil
+	}
+	return &types.Import{
+		Base: types.Base{
+			Name: file.Name.Name,
+		},
+		Package: strings.Trim(packagePath, `"`),
+	}
+}
+
 func parseComments(group *ast.CommentGroup) (comments []string) {
 	if group == nil {
 		return
@@ -76,9 +89,9 @@ func parseComments(group *ast.CommentGroup) (comments []string) {
 	return
 }
 
-func parseTopLevelDeclarations(decls []ast.Decl, file *types.File, pp *types.Import) error {
+func parseTopLevelDeclarations(decls []ast.Decl, file *types.File, pp *types.Import, srcDir string) error {
 	for i := range decls {
-		err := parseDeclaration(decls[i], file, pp)
+		err := parseDeclaration(decls[i], file, pp, srcDir)
 		if err != nil {
 			return err
 		}
@@ -86,18 +99,14 @@ func parseTopLevelDeclarations(decls []ast.Decl, file *types.File, pp *types.Imp
 	return nil
 }
 
-func constructAliasName(spec *ast.ImportSpec) string {
+func constructAliasName(spec *ast.ImportSpec, srcDir string) string {
 	if spec.Name != nil {
 		return spec.Name.Name
 	}
-	return constructAliasNameString(spec.Path.Value)
-}
-
-func constructAliasNameString(str string) string {
-	return path.Base(strings.Trim(str, `"`))
+	return packageName(strings.Trim(spec.Path.Value, `"`), srcDir)
 }
 
-func parseDeclaration(decl ast.Decl, file *types.File, pp *types.Import) error {
+func parseDeclaration(decl ast.Decl, file *types.File, pp *types.Import, srcDir string) error {
 	switch d := decl.(type) {
 	case *ast.GenDecl:
 		switch d.Tok {
@@ -109,13 +118,20 @@ func parseDeclaration(decl ast.Decl, file *types.File, pp *types.Import) error {
 				if !ok {
 					continue // if !ok then comment
 				}
-				alias := constructAliasName(spec)
+				alias := constructAliasName(spec, srcDir)
 				imp := types.Import{
 					Base: types.Base{
 						Name: alias,
 						Docs: parseComments(spec.Doc),
 					},
 					Package: strings.Trim(spec.Path.Value, `"`),
+					Dot:     alias == ".",
+					Blank:   alias == "_",
+				}
+				imp.Cgo = imp.Package == "C"
+				if imp.Cgo && spec.Doc == nil && !d.Lparen.IsValid() {
+					// Preamble of standalone `import "C"` is a doc of declaration.
+					imp.Docs = parseComments(d.Doc)
 				}
 
 				imports = append(imports, imp)
@@ -134,41 +150,10 @@ func parseDeclaration(decl ast.Decl, file *types.File, pp *types.Import) error {
 			}
 			file.Constants = append(file.Constants, consts...)
 		case token.TYPE:
-			typeSpec := d.Specs[0].(*ast.TypeSpec)
-			switch t := typeSpec.Type.(type) {
-			case *ast.InterfaceType:
-				methods, err := parseInterfaceMethods(t, file, pp)
-				if err != nil {
+			for _, spec := range d.Specs {
+				if err := parseTypeSpec(spec.(*ast.TypeSpec), d, file, pp); err != nil {
 					return err
 				}
-				file.Interfaces = append(file.Interfaces, types.Interface{
-					Base: types.Base{
-						Name: typeSpec.Name.Name,
-						Docs: parseComments(d.Doc),
-					},
-					Methods: methods,
-				})
-			case *ast.StructType:
-				strFields, err := parseStructFields(t, file, pp)
-				if err != nil {
-					return fmt.Errorf("%s: can't parse struct fields: %v", typeSpec.Name.Name, err)
-				}
-				file.Structures = append(file.Structures, types.Struct{
-					Base: types.Base{
-						Name: typeSpec.Name.Name,
-						Docs: parseComments(d.Doc),
-					},
-					Fields: strFields,
-				})
-			default:
-				newType, err := parseByType(typeSpec.Type, file, pp)
-				if err != nil {
-					return fmt.Errorf("%s: can't parse type: %v", typeSpec.Name.Name, err)
-				}
-				file.Types = append(file.Types, types.FileType{Base: types.Base{
-					Name: typeSpec.Name.Name,
-					Docs: parseComments(d.Doc),
-				}, Type: newType})
 			}
 		}
 	case *ast.FuncDecl:
@@ -198,6 +183,47 @@ func parseDeclaration(decl ast.Decl, file *types.File, pp *types.Import) error {
 	return nil
 }
 
+func parseTypeSpec(typeSpec *ast.TypeSpec, d *ast.GenDecl, file *types.File, pp *types.Import) error {
+	docs := typeSpec.Doc
+	if docs == nil {
+		docs = d.Doc
+	}
+	switch t := typeSpec.Type.(type) {
+	case *ast.InterfaceType:
+		iface, err := parseInterface(t, file, pp)
+		if err != nil {
+			return err
+		}
+		iface.Base = types.Base{
+			Name: typeSpec.Name.Name,
+			Docs: parseComments(docs),
+		}
+		file.Interfaces = append(file.Interfaces, *iface)
+	case *ast.StructType:
+		strFields, err := parseStructFields(t, file, pp)
+		if err != nil {
+			return fmt.Errorf("%s: can't parse struct fields: %v", typeSpec.Name.Name, err)
+		}
+		file.Structures = append(file.Structures, types.Struct{
+			Base: types.Base{
+				Name: typeSpec.Name.Name,
+				Docs: parseComments(docs),
+			},
+			Fields: strFields,
+		})
+	default:
+		newType, err := parseByType(typeSpec.Type, file, pp)
+		if err != nil {
+			return fmt.Errorf("%s: can't parse type: %v", typeSpec.Name.Name, err)
+		}
+		file.Types = append(file.Types, types.FileType{Base: types.Base{
+			Name: typeSpec.Name.Name,
+			Docs: parseComments(docs),
+		}, Type: newType})
+	}
+	return nil
+}
+
 func parseReceiver(list *ast.FieldList, file *types.File, pp *types.Import) (*types.Variable, error) {
 	recv, err := parseParams(list, file, pp)
 	if err != nil {
@@ -209,38 +235,190 @@ func parseReceiver(list *ast.FieldList, file *types.File, pp *types.Import) (*ty
 	return nil, fmt.Errorf("reciever not found for %d:%d", list.Pos(), list.End())
 }
 
+// Parses all specs of declaration. Constant spec without type and values repeats the previous one.
+// Value of constant, which depends on iota, is its computed value or, when it can't be computed,
+// its expression, where iota is replaced by its value, e.g. `time.Second * 2`.
 func parseVariables(decl *ast.GenDecl, file *types.File, pp *types.Import) (vars []types.Variable, err error) {
-	spec := decl.Specs[0].(*ast.ValueSpec)
-	if len(spec.Values) > 0 && len(spec.Values) != len(spec.Names) {
-		return nil, fmt.Errorf("amount of variables and their values not same %d:%d", spec.Pos(), spec.End())
+	var (
+		lastType   ast.Expr
+		lastValues []ast.Expr
+		consts     = make(map[string]constant.Value)
+	)
+	for iota, s := range decl.Specs {
+		spec := s.(*ast.ValueSpec)
+		specType, values := spec.Type, spec.Values
+		if decl.Tok == token.CONST {
+			if specType == nil && len(values) == 0 {
+				specType, values = lastType, lastValues
+			}
+			lastType, lastValues = specType, values
+		}
+		// Variables are assigned by results of call, their types are unknown.
+		tuple := decl.Tok == token.VAR && len(values) == 1 && len(spec.Names) > 1
+		if len(values) > 0 && len(values) != len(spec.Names) && !tuple {
+			return nil, fmt.Errorf("amount of variables and their values not same %d:%d", spec.Pos(), spec.End())
+		}
+		docs := spec.Doc
+		if docs == nil {
+			docs = decl.Doc
+		}
+		for i, name := range spec.Names {
+			variable := types.Variable{
+				Base: types.Base{
+					Name: name.Name,
+					Docs: parseComments(docs),
+				},
+			}
+			var (
+				valType types.Type
+				err     error
+			)
+			if specType != nil {
+				valType, err = parseByType(specType, file, pp)
+				if err != nil {
+					return nil, fmt.Errorf("can't parse type: %v", err)
+				}
+			} else if len(values) > 0 && !tuple {
+				valType, err = parseByValue(values[i], file)
+				if err != nil {
+					return nil, fmt.Errorf("can't parse type: %v", err)
+				}
+			}
+
+			variable.Type = valType
+			if tuple {
+				variable.Value, variable.ValueIndex = exprString(values[0]), i
+			} else if len(values) > 0 {
+				variable.Value = exprString(values[i])
+			}
+			if decl.Tok == token.CONST && len(values) > 0 {
+				value := evalConst(values[i], iota, consts)
+				if value != nil {
+					consts[name.Name] = value
+				}
+				if value != nil && (len(spec.Values) == 0 || usesIota(values[i])) {
+					variable.Value = constString(value)
+				} else if value == nil && usesIota(values[i]) {
+					// Expression of iota without the index is not a value of constant.
+					variable.Value = substituteIota(values[i], iota)
+				}
+			}
+			vars = append(vars, variable)
+		}
 	}
-	for i, name := range spec.Names {
-		variable := types.Variable{
-			Base: types.Base{
-				Name: name.Name,
-				Docs: parseComments(decl.Doc),
-			},
+	return
+}
+
+// Computes constant expression, which uses literals, iota and constants of the same declaration.
+// Returns nil, when expression can't be computed.
+func evalConst(expr ast.Expr, iota int, consts map[string]constant.Value) constant.Value {
+	switch e := expr.(type) {
+	case *ast.BasicLit:
+		v := constant.MakeFromLiteral(e.Value, e.Kind, 0)
+		if v.Kind() == constant.Unknown {
+			return nil
 		}
-		var (
-			valType types.Type
-			err     error
-		)
-		if spec.Type != nil {
-			valType, err = parseByType(spec.Type, file, pp)
-			if err != nil {
-				return nil, fmt.Errorf("can't parse type: %v", err)
+		return v
+	case *ast.Ident:
+		switch e.Name {
+		case "iota":
+			return constant.MakeInt64(int64(iota))
+		case "true", "false":
+			return constant.MakeBool(e.Name == "true")
+		}
+		return consts[e.Name]
+	case *ast.ParenExpr:
+		return evalConst(e.X, iota, consts)
+	case *ast.UnaryExpr:
+		x := evalConst(e.X, iota, consts)
+		if x == nil {
+			return nil
+		}
+		switch e.Op {
+		case token.ADD, token.SUB, token.XOR, token.NOT:
+			return constant.UnaryOp(e.Op, x, 0)
+		}
+	case *ast.BinaryExpr:
+		x, y := evalConst(e.X, iota, consts), evalConst(e.Y, iota, consts)
+		if x == nil || y == nil {
+			return nil
+		}
+		switch e.Op {
+		case token.SHL, token.SHR:
+			s, ok := constant.Uint64Val(constant.ToInt(y))
+			if !ok || x.Kind() != constant.Int {
+				return nil
 			}
-		} else {
-			valType, err = parseByValue(spec.Values[i], file)
-			if err != nil {
-				return nil, fmt.Errorf("can't parse type: %v", err)
+			return constant.Shift(x, e.Op, uint(s))
+		case token.EQL, token.NEQ, token.LSS, token.LEQ, token.GTR, token.GEQ:
+			return constant.MakeBool(constant.Compare(x, e.Op, y))
+		case token.QUO:
+			if constant.Sign(y) == 0 {
+				return nil
+			}
+			if x.Kind() == constant.Int && y.Kind() == constant.Int {
+				return constant.BinaryOp(x, token.QUO_ASSIGN, y)
+			}
+			return constant.BinaryOp(x, e.Op, y)
+		case token.REM:
+			if constant.Sign(y) == 0 {
+				return nil
+			}
+			return constant.BinaryOp(x, e.Op, y)
+		case token.ADD, token.SUB, token.MUL, token.AND, token.OR, token.XOR, token.AND_NOT, token.LAND, token.LOR:
+			if (x.Kind() == constant.String) != (y.Kind() == constant.String) {
+				return nil
+			}
+			return constant.BinaryOp(x, e.Op, y)
+		}
+	}
+	return nil
+}
+
+func usesIota(expr ast.Expr) bool {
+	found := false
+	ast.Inspect(expr, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Ident); ok && id.Name == "iota" {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// Returns source of expression, where iota is replaced by its value.
+// Expression is parsed again, because it may be shared by several specs.
+func substituteIota(expr ast.Expr, iota int) string {
+	copied, err := astparser.ParseExpr(exprString(expr))
+	if err != nil {
+		return ""
+	}
+	var substitute func(ast.Node) bool
+	substitute = func(n ast.Node) bool {
+		switch nn := n.(type) {
+		case *ast.SelectorExpr:
+			// Selected field or method may be named iota too.
+			ast.Inspect(nn.X, substitute)
+			return false
+		case *ast.Ident:
+			if nn.Name == "iota" {
+				nn.Name = strconv.Itoa(iota)
 			}
 		}
+		return true
+	}
+	ast.Inspect(copied, substitute)
+	return exprString(copied)
+}
 
-		variable.Type = valType
-		vars = append(vars, variable)
+// Returns Go literal of constant.
+func constString(v constant.Value) string {
+	if v.Kind() == constant.Float {
+		if f, exact := constant.Float64Val(v); exact {
+			return strconv.FormatFloat(f, 'g', -1, 64)
+		}
 	}
-	return
+	return v.ExactString()
 }
 
 func setupImportIfNeed(tt types.Type, tImport *types.Import) types.Type {
@@ -254,99 +432,22 @@ func setupImportIfNeed(tt types.Type, tImport *types.Import) types.Type {
 }
 
 // Fill provided types.Type for cases, when variable's type is provided.
-func parseByType(spec interface{}, file *types.File, pp *types.Import) (tt types.Type, err error) {
-	switch t := spec.(type) {
-	case *ast.Ident:
-		return types.TName{TypeName: t.Name}, nil
-	case *ast.SelectorExpr:
-		im, err := findImportByAlias(file, t.X.(*ast.Ident).Name)
-		if err != nil {
-			return nil, fmt.Errorf("%s: %v", t.Sel.Name, err)
-		}
-		if im == nil {
-			return nil, fmt.Errorf("wrong import %d:%d", t.Pos(), t.End())
-		}
-		return types.TImport{Import: im, Next: types.TName{TypeName: t.Sel.Name}}, nil
-	case *ast.StarExpr:
-		next, err := parseByType(t.X, file, pp)
-		if err != nil {
-			return nil, err
-		}
-		if next.TypeOf() == types.T_Pointer {
-			return types.TPointer{Next: next.(types.TPointer).NextType(), NumberOfPointers: 1 + next.(types.TPointer).NumberOfPointers}, nil
-		}
-		return types.TPointer{Next: next, NumberOfPointers: 1}, nil
-	case *ast.ArrayType:
-		l := parseArrayLen(t)
-		next, err := parseByType(t.Elt, file, pp)
-		if err != nil {
-			return nil, err
-		}
-		switch l {
-		case -3, -2:
-			return types.TArray{Next: next, IsSlice: true}, nil
-		case -1:
-			return types.TArray{Next: next, IsEllipsis: true}, nil
-		default:
-			return types.TArray{Next: next, ArrayLen: l}, nil
-		}
-	case *ast.MapType:
-		key, err := parseByType(t.Key, file, pp)
-		if err != nil {
-			return nil, err
-		}
-		value, err := parseByType(t.Value, file, pp)
-		if err != nil {
-			return nil, err
-		}
-		return types.TMap{Key: key, Value: value}, nil
-	case *ast.InterfaceType:
-		methods, err := parseInterfaceMethods(t, file, pp)
-		if err != nil {
-			return nil, err
-		}
-		return types.TInterface{
-			Interface: &types.Interface{
-				Base:    types.Base{},
-				Methods: methods,
-			},
-		}, nil
-	case *ast.Ellipsis:
-		next, err := parseByType(t.Elt, file, pp)
-		if err != nil {
-			return nil, err
-		}
-		return types.TEllipsis{Next: next}, nil
-	case *ast.ChanType:
-		next, err := parseByType(t.Value, file, pp)
-		if err != nil {
-			return nil, err
-		}
-		return types.TChan{Next: next, Direction: int(t.Dir)}, nil
-	case *ast.ParenExpr:
-		return parseByType(t.X, file, pp)
-	case *ast.BadExpr:
-		return nil, fmt.Errorf("bad expression")
-	default:
-		return nil, fmt.Errorf("%v: %T", ErrUnexpectedSpec, t)
-	}
+func parseByType(spec ast.Expr, file *types.File, pp *types.Import) (tt types.Type, err error) {
+	return types.TypeOfExpr(spec, importFinder(file))
 }
 
-func parseArrayLen(t *ast.ArrayType) int {
-	if t == nil {
-		return -2
-	}
-	switch l := t.Len.(type) {
-	case *ast.Ellipsis:
-		return -1
-	case *ast.BasicLit:
-		if l.Kind == token.INT {
-			x, _ := strconv.Atoi(l.Value)
-			return x
-		}
-		return 0
+// Finds imports of file by their aliases.
+func importFinder(file *types.File) types.ImportFinder {
+	return func(alias string) (*types.Import, error) {
+		return findImportByAlias(file, alias)
 	}
-	return -3
+}
+
+// Returns source of expression.
+func exprString(expr ast.Expr) string {
+	var buf bytes.Buffer
+	printer.Fprint(&buf, token.NewFileSet(), expr)
+	return buf.String()
 }
 
 // Fill provided types.Type for cases, when variable's value is provided.
@@ -357,7 +458,11 @@ func parseByValue(spec interface{}, file *types.File) (tt types.Type, err error)
 	case *ast.CompositeLit:
 		return parseByValue(t.Type, file)
 	case *ast.SelectorExpr:
-		im, err := findImportByAlias(file, t.X.(*ast.Ident).Name)
+		x, ok := t.X.(*ast.Ident)
+		if !ok {
+			return nil, nil
+		}
+		im, err := findImportByAlias(file, x.Name)
 		if err != nil {
 			return nil, fmt.Errorf("%s: %v", t.Sel.Name, err)
 		}
@@ -370,34 +475,9 @@ func parseByValue(spec interface{}, file *types.File) (tt types.Type, err error)
 	}
 }
 
-// Collects and returns all interface methods.
-func parseInterfaceMethods(ifaceType *ast.InterfaceType, file *types.File, pp *types.Import) ([]*types.Function, error) {
-	var fns []*types.Function
-	if ifaceType.Methods != nil {
-		for _, method := range ifaceType.Methods.List {
-			fn, err := parseFunction(method, file, pp)
-			if err != nil {
-				return nil, err
-			}
-			fns = append(fns, fn)
-		}
-	}
-	return fns, nil
-}
-
-func parseFunction(funcField *ast.Field, file *types.File, pp *types.Import) (*types.Function, error) {
-	fn := &types.Function{
-		Base: types.Base{
-			Name: funcField.Names[0].Name,
-			Docs: parseComments(funcField.Doc),
-		},
-	}
-	funcType := funcField.Type.(*ast.FuncType)
-	err := parseFuncParamsAndResults(funcType, fn, file, pp)
-	if err != nil {
-		return nil, err
-	}
-	return fn, nil
+// Collects all interface methods and embedded interfaces.
+func parseInterface(ifaceType *ast.InterfaceType, file *types.File, pp *types.Import) (*types.Interface, error) {
+	return types.InterfaceOfExpr(ifaceType, importFinder(file))
 }
 
 func parseFuncParamsAndResults(funcType *ast.FuncType, fn *types.Function, file *types.File, pp *types.Import) error {
@@ -416,39 +496,7 @@ func parseFuncParamsAndResults(funcType *ast.FuncType, fn *types.Function, file
 
 // Collects and returns all args/results from function or fields from structure.
 func parseParams(fields *ast.FieldList, file *types.File, pp *types.Import) ([]types.Variable, error) {
-	var vars []types.Variable
-	if fields == nil {
-		return vars, nil
-	}
-	for _, field := range fields.List {
-		if field.Type == nil {
-			return nil, fmt.Errorf("param's type is nil %d:%d", field.Pos(), field.End())
-		}
-		t, err := parseByType(field.Type, file, pp)
-		if err != nil {
-			return nil, fmt.Errorf("wrong type of %s: %v", strings.Join(namesOfIdents(field.Names), ","), err)
-		}
-		docs := parseComments(field.Doc)
-		if len(field.Names) == 0 {
-			vars = append(vars, types.Variable{
-				Base: types.Base{
-					Docs: docs,
-				},
-				Type: t,
-			})
-		} else {
-			for _, name := range field.Names {
-				vars = append(vars, types.Variable{
-					Base: types.Base{
-						Name: name.Name,
-						Docs: docs,
-					},
-					Type: t,
-				})
-			}
-		}
-	}
-	return vars, nil
+	return types.ParamsOfExpr(fields, importFinder(file))
 }
 
 func parseTags(lit *ast.BasicLit) (tags map[string][]string, raw string) {
@@ -498,7 +546,7 @@ func findImportByAlias(file *types.File, alias string) (*types.Import, error) {
 	}
 	// try to find by last package path
 	for _, imp := range file.Imports {
-		if alias == path.Base(imp.Package) {
+		if !imp.Dot && !imp.Blank && alias == types.AssumedPackageName(imp.Package) {
 			return &imp, nil
 		}
 	}
@@ -506,6 +554,24 @@ func findImportByAlias(file *types.File, alias string) (*types.Import, error) {
 	return nil, fmt.Errorf("%v: %s", ErrCouldNotResolvePackage, alias)
 }
 
+// Links methods with structures and types, declared in other files of the same package.
+func linkPackageMethods(files []*types.File) {
+	for _, file := range files {
+		for i := range file.Methods {
+			for _, other := range files {
+				if other == file {
+					continue
+				}
+				if structure, _ := findStructByMethod(other, &file.Methods[i]); structure != nil {
+					structure.Methods = append(structure.Methods, &file.Methods[i])
+				} else if typee, _ := findTypeByMethod(other, &file.Methods[i]); typee != nil {
+					typee.Methods = append(typee.Methods, &file.Methods[i])
+				}
+			}
+		}
+	}
+}
+
 func findStructByMethod(file *types.File, method *types.Method) (*types.Struct, error) {
 	recType := method.Receiver.Type
 	if !IsCommonReciever(recType) {
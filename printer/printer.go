@@ -0,0 +1,426 @@
+// Package printer renders declarations model back to gofmt'd Go source.
+package printer
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/vetcher/godecl/types"
+)
+
+// Config controls rendering of declarations.
+type Config struct {
+	// Body returns statements of function or method body. recv is nil for functions.
+	// When Body is nil, every body is `panic("not implemented")`.
+	Body func(fn *types.Function, recv *types.Variable) string
+}
+
+var defaultConfig = &Config{}
+
+// Returns gofmt'd source of node, which is one of types.File, types.Struct, types.Interface,
+// types.Function, types.Method, types.FileType, types.Variable (as variable declaration)
+// or pointers to them, or types.Type.
+func Source(node interface{}) ([]byte, error) {
+	return defaultConfig.Source(node)
+}
+
+// Writes gofmt'd source of node to w. See Source for a list of supported nodes.
+func Fprint(w io.Writer, node interface{}) error {
+	return defaultConfig.Fprint(w, node)
+}
+
+// Returns source of type expression.
+func TypeString(t types.Type) string {
+	var p printer
+	p.typ(t)
+	return p.String()
+}
+
+// Returns signature of function in one line, e.g. `Get(ctx context.Context, id int) (*User, error)`.
+func FuncSignature(fn *types.Function) string {
+	var p printer
+	p.WriteString(fn.Name)
+	p.signature(fn)
+	return p.String()
+}
+
+// Returns specification of constant or variables, which share value, in one line,
+// e.g. `Answer = 42`, `W io.Writer` or `r, w = io.Pipe()`. Type is omitted, when it was guessed by parser from value.
+func VariableString(vars ...*types.Variable) string {
+	var p printer
+	p.valueSpec(vars)
+	return p.String()
+}
+
+// Groups variables, assigned by results of one call, e.g. `var a, b = f()`.
+func GroupVars(vars []types.Variable) [][]*types.Variable {
+	var groups [][]*types.Variable
+	for i := 0; i < len(vars); i++ {
+		group := []*types.Variable{&vars[i]}
+		for i+1 < len(vars) && vars[i+1].ValueIndex == vars[i].ValueIndex+1 && vars[i+1].Value == vars[i].Value {
+			i++
+			group = append(group, &vars[i])
+		}
+		groups = append(groups, group)
+	}
+	return groups
+}
+
+func (c *Config) Fprint(w io.Writer, node interface{}) error {
+	src, err := c.Source(node)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(src)
+	return err
+}
+
+func (c *Config) Source(node interface{}) ([]byte, error) {
+	p := printer{config: c}
+	if t, ok := node.(types.Type); ok {
+		// Format type as part of declaration to get proper indentation of interfaces.
+		const prefix = "type _ "
+		p.WriteString(prefix)
+		p.typ(t)
+		src, err := format.Source(p.Bytes())
+		if err != nil {
+			return nil, fmt.Errorf("can't format %s: %v", p.String(), err)
+		}
+		return bytes.TrimPrefix(src, []byte(prefix)), nil
+	}
+	if err := p.node(node); err != nil {
+		return nil, err
+	}
+	src, err := format.Source(p.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("can't format generated source: %v", err)
+	}
+	return src, nil
+}
+
+type printer struct {
+	bytes.Buffer
+	config *Config
+	// Paths of dot-imported packages, which types were printed.
+	dots map[string]bool
+}
+
+func (p *printer) node(node interface{}) error {
+	switch n := node.(type) {
+	case types.File:
+		return p.file(&n)
+	case *types.File:
+		return p.file(n)
+	case types.Struct:
+		p.structure(&n)
+	case *types.Struct:
+		p.structure(n)
+	case types.Interface:
+		p.iface(&n)
+	case *types.Interface:
+		p.iface(n)
+	case types.FileType:
+		p.fileType(&n)
+	case *types.FileType:
+		p.fileType(n)
+	case types.Function:
+		p.function(&n, nil)
+	case *types.Function:
+		p.function(n, nil)
+	case types.Method:
+		p.function(&n.Function, &n.Receiver)
+	case *types.Method:
+		p.function(&n.Function, &n.Receiver)
+	case types.Variable:
+		return p.variable("var", &n)
+	case *types.Variable:
+		return p.variable("var", n)
+	default:
+		return fmt.Errorf("unexpected node %T", node)
+	}
+	return nil
+}
+
+func (p *printer) file(f *types.File) error {
+	var body printer
+	body.config = p.config
+	for i := range f.Constants {
+		if err := body.variable("const", &f.Constants[i]); err != nil {
+			return err
+		}
+	}
+	for _, vars := range GroupVars(f.Vars) {
+		if err := body.variable("var", vars...); err != nil {
+			return err
+		}
+	}
+	for i := range f.Types {
+		body.fileType(&f.Types[i])
+	}
+	for i := range f.Interfaces {
+		body.iface(&f.Interfaces[i])
+	}
+	for i := range f.Structures {
+		body.structure(&f.Structures[i])
+	}
+	for i := range f.Functions {
+		body.function(&f.Functions[i], nil)
+	}
+	for i := range f.Methods {
+		body.function(&f.Methods[i].Function, &f.Methods[i].Receiver)
+	}
+
+	p.docs(f.Docs)
+	p.WriteString("package " + f.Name + "\n\n")
+	// Only used imports are printed: parser adds path of the package itself to imports
+	// and bodies of functions, which could use other imports, are not a part of the model.
+	// Blank imports are always used, `import "C"` is printed separately to keep its preamble.
+	var imports []types.Import
+	for _, imp := range f.Imports {
+		switch {
+		case imp.Cgo:
+			p.docs(imp.Docs)
+			p.WriteString("import \"C\"\n\n")
+		case imp.Blank, imp.Dot && body.dots[imp.Package], !imp.Dot && isImportUsed(imp.Name, body.Bytes()):
+			imports = append(imports, imp)
+		}
+	}
+	if len(imports) > 0 {
+		p.WriteString("import (\n")
+		for _, imp := range imports {
+			p.docs(imp.Docs)
+			if imp.Name != types.AssumedPackageName(imp.Package) {
+				p.WriteString(imp.Name + " ")
+			}
+			p.WriteString(strconv.Quote(imp.Package) + "\n")
+		}
+		p.WriteString(")\n\n")
+	}
+	p.Write(body.Bytes())
+	return nil
+}
+
+func isImportUsed(alias string, src []byte) bool {
+	return regexp.MustCompile(`\b` + regexp.QuoteMeta(alias) + `\.`).Match(src)
+}
+
+func (p *printer) docs(docs []string) {
+	for _, d := range docs {
+		p.WriteString(d + "\n")
+	}
+}
+
+// Names of types, which parser assigns to variables, initialized by basic literals.
+var literalTypes = map[string]bool{"INT": true, "FLOAT": true, "IMAG": true, "CHAR": true, "STRING": true}
+
+// Prints declaration of constant or variables, which share value, e.g. `var a, b = f()`.
+// Type is omitted, when it was guessed by parser from value.
+func (p *printer) variable(tok string, vars ...*types.Variable) error {
+	v := vars[0]
+	if v.Value == "" && (v.Type == nil || tok == "const") {
+		return fmt.Errorf("%s %s: value is unknown", tok, v.Name)
+	}
+	p.docs(v.Docs)
+	p.WriteString(tok + " ")
+	p.valueSpec(vars)
+	p.WriteString("\n\n")
+	return nil
+}
+
+// Prints names, type and value of constant or variables, which share value.
+func (p *printer) valueSpec(vars []*types.Variable) {
+	v := vars[0]
+	for i := range vars {
+		if i > 0 {
+			p.WriteString(", ")
+		}
+		p.WriteString(vars[i].Name)
+	}
+	if t := v.Type; t != nil && !(v.Value != "" && isGuessedType(t)) {
+		p.WriteString(" ")
+		p.typ(t)
+	}
+	if v.Value != "" {
+		p.WriteString(" = " + v.Value)
+	}
+}
+
+func isGuessedType(t types.Type) bool {
+	switch tt := t.(type) {
+	case nil:
+		return true
+	case types.TName:
+		return literalTypes[tt.TypeName]
+	case types.TImport:
+		return tt.Next == nil
+	}
+	return false
+}
+
+func (p *printer) fileType(t *types.FileType) {
+	p.docs(t.Docs)
+	p.WriteString("type " + t.Name + " ")
+	p.typ(t.Type)
+	p.WriteString("\n\n")
+}
+
+func (p *printer) iface(i *types.Interface) {
+	p.docs(i.Docs)
+	p.WriteString("type " + i.Name + " ")
+	p.interfaceType(i)
+	p.WriteString("\n\n")
+}
+
+func (p *printer) interfaceType(i *types.Interface) {
+	if len(i.Methods) == 0 && len(i.Embedded) == 0 {
+		p.WriteString("interface{}")
+		return
+	}
+	p.WriteString("interface {\n")
+	for _, e := range i.Embedded {
+		p.typ(e)
+		p.WriteString("\n")
+	}
+	for _, m := range i.Methods {
+		p.docs(m.Docs)
+		p.WriteString(m.Name)
+		p.signature(m)
+		p.WriteString("\n")
+	}
+	p.WriteString("}")
+}
+
+func (p *printer) structure(s *types.Struct) {
+	p.docs(s.Docs)
+	p.WriteString("type " + s.Name + " struct {\n")
+	for _, f := range s.Fields {
+		p.docs(f.Docs)
+		if f.Name != "" {
+			p.WriteString(f.Name + " ")
+		}
+		p.typ(f.Type)
+		if f.RawTags != "" {
+			p.WriteString(" " + f.RawTags)
+		}
+		p.WriteString("\n")
+	}
+	p.WriteString("}\n\n")
+}
+
+func (p *printer) function(fn *types.Function, recv *types.Variable) {
+	p.docs(fn.Docs)
+	p.WriteString("func ")
+	if recv != nil {
+		p.WriteString("(")
+		p.param(*recv)
+		p.WriteString(") ")
+	}
+	p.WriteString(fn.Name)
+	p.signature(fn)
+	body := `panic("not implemented")`
+	if p.config != nil && p.config.Body != nil {
+		body = p.config.Body(fn, recv)
+	}
+	p.WriteString(" {\n" + body + "\n}\n\n")
+}
+
+// Prints params and results of function.
+func (p *printer) signature(fn *types.Function) {
+	p.WriteString("(")
+	p.params(fn.Args)
+	p.WriteString(")")
+	switch {
+	case len(fn.Results) == 0:
+	case len(fn.Results) == 1 && fn.Results[0].Name == "":
+		p.WriteString(" ")
+		p.typ(fn.Results[0].Type)
+	default:
+		p.WriteString(" (")
+		p.params(fn.Results)
+		p.WriteString(")")
+	}
+}
+
+func (p *printer) params(vars []types.Variable) {
+	for i, v := range vars {
+		if i > 0 {
+			p.WriteString(", ")
+		}
+		p.param(v)
+	}
+}
+
+func (p *printer) param(v types.Variable) {
+	if v.Name != "" {
+		p.WriteString(v.Name + " ")
+	}
+	p.typ(v.Type)
+}
+
+func (p *printer) typ(t types.Type) {
+	switch tt := t.(type) {
+	case types.TName:
+		p.WriteString(tt.TypeName)
+	case types.TPointer:
+		p.WriteString(strings.Repeat("*", tt.NumberOfPointers))
+		p.typ(tt.Next)
+	case types.TArray:
+		switch {
+		case tt.IsSlice:
+			p.WriteString("[]")
+		case tt.IsEllipsis:
+			p.WriteString("[...]")
+		default:
+			p.WriteString("[" + strconv.Itoa(tt.ArrayLen) + "]")
+		}
+		p.typ(tt.Next)
+	case types.TMap:
+		p.WriteString("map[")
+		p.typ(tt.Key)
+		p.WriteString("]")
+		p.typ(tt.Value)
+	case types.TInterface:
+		if tt.Interface == nil {
+			p.WriteString("interface{}")
+			return
+		}
+		p.interfaceType(tt.Interface)
+	case types.TImport:
+		switch {
+		case tt.Import == nil:
+		case tt.Import.Dot:
+			if p.dots == nil {
+				p.dots = make(map[string]bool)
+			}
+			p.dots[tt.Import.Package] = true
+		default:
+			p.WriteString(tt.Import.Name + ".")
+		}
+		p.typ(tt.Next)
+	case types.TEllipsis:
+		p.WriteString("...")
+		p.typ(tt.Next)
+	case types.TChan:
+		switch tt.Direction {
+		case types.ChanDirSend:
+			p.WriteString("chan<- ")
+		case types.ChanDirRecv:
+			p.WriteString("<-chan ")
+		default:
+			p.WriteString("chan ")
+		}
+		// Without parens `chan (<-chan int)` would be `chan<- chan int`.
+		if next, ok := tt.Next.(types.TChan); ok && next.Direction == types.ChanDirRecv && tt.Direction == types.ChanDirAny {
+			p.WriteString("(")
+			p.typ(tt.Next)
+			p.WriteString(")")
+			return
+		}
+		p.typ(tt.Next)
+	}
+}
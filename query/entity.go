@@ -0,0 +1,242 @@
+package query
+
+import (
+	"go/ast"
+	"regexp"
+	"strings"
+
+	"github.com/vetcher/godecl/printer"
+	"github.com/vetcher/godecl/types"
+)
+
+// Kinds of entities.
+const (
+	KindConst     = "const"
+	KindVar       = "var"
+	KindType      = "type"
+	KindInterface = "interface"
+	KindStruct    = "struct"
+	KindFunc      = "func"
+	KindMethod    = "method"
+	KindField     = "field"
+)
+
+var kinds = map[string]bool{
+	KindConst: true, KindVar: true, KindType: true, KindInterface: true,
+	KindStruct: true, KindFunc: true, KindMethod: true, KindField: true,
+}
+
+// Entity is a declaration of package or a part of declaration: field of structure or method of interface.
+type Entity struct {
+	Kind string `json:"kind"`
+	// Qualified name of entity, as types.Index builds it.
+	Name    string `json:"name"`
+	Package string `json:"package,omitempty"`
+	// Name of structure or interface, which contains field or method, or receiver type name of method.
+	Owner string `json:"owner,omitempty"`
+	// One of *types.Variable, *types.FileType, *types.Interface, *types.Struct, *types.Function,
+	// *types.Method or *types.StructField.
+	Decl types.Node `json:"decl"`
+
+	base *types.Base
+	// Finds embedded interfaces of interface.
+	scope types.Resolver
+}
+
+// Returns entities of packages, which match query.
+func (q *Query) Find(pkgs ...*types.Package) []*Entity {
+	var found []*Entity
+	for _, e := range entities(q.Importer, pkgs) {
+		if q.Match(e) {
+			found = append(found, e)
+		}
+	}
+	return found
+}
+
+// Returns all named entities of packages in order of their declaration.
+func Entities(pkgs ...*types.Package) []*Entity {
+	return entities(nil, pkgs)
+}
+
+func entities(imp types.Importer, pkgs []*types.Package) []*Entity {
+	index := types.NewIndex(pkgs...)
+	var entities []*Entity
+	add := func(pkg *types.Package, kind, owner string, base *types.Base, decl types.Node) {
+		name := index.QualifiedName(decl)
+		if name == "" {
+			return
+		}
+		entities = append(entities, &Entity{Kind: kind, Name: name, Package: pkg.Path, Owner: owner, Decl: decl, base: base, scope: pkg.Scope(imp)})
+	}
+	for _, pkg := range pkgs {
+		for _, f := range pkg.Files {
+			for i := range f.Constants {
+				add(pkg, KindConst, "", &f.Constants[i].Base, &f.Constants[i])
+			}
+			for i := range f.Vars {
+				add(pkg, KindVar, "", &f.Vars[i].Base, &f.Vars[i])
+			}
+			for i := range f.Types {
+				add(pkg, KindType, "", &f.Types[i].Base, &f.Types[i])
+			}
+			for i := range f.Interfaces {
+				iface := &f.Interfaces[i]
+				add(pkg, KindInterface, "", &iface.Base, iface)
+				for _, m := range iface.Methods {
+					add(pkg, KindMethod, iface.Name, &m.Base, m)
+				}
+			}
+			for i := range f.Structures {
+				s := &f.Structures[i]
+				add(pkg, KindStruct, "", &s.Base, s)
+				for j := range s.Fields {
+					add(pkg, KindField, s.Name, &s.Fields[j].Base, &s.Fields[j])
+				}
+			}
+			for i := range f.Functions {
+				add(pkg, KindFunc, "", &f.Functions[i].Base, &f.Functions[i])
+			}
+			for i := range f.Methods {
+				m := &f.Methods[i]
+				if recv := types.TypeName(m.Receiver.Type); recv != nil {
+					add(pkg, KindMethod, *recv, &m.Base, m)
+				}
+			}
+		}
+	}
+	return entities
+}
+
+// Returns name of entity, embedded fields are named by their types.
+func (e *Entity) name() string {
+	if f, ok := e.Decl.(*types.StructField); ok && f.Name == "" {
+		if name := types.TypeName(f.Type); name != nil {
+			return *name
+		}
+	}
+	return e.base.Name
+}
+
+func (e *Entity) exported() bool {
+	return ast.IsExported(e.name())
+}
+
+func (e *Entity) matchName(p *regexp.Regexp, qualified bool) bool {
+	if qualified {
+		return e.Owner != "" && p.MatchString(e.Owner+"."+e.name())
+	}
+	return p.MatchString(e.name())
+}
+
+func (e *Entity) annotated(key string, p *regexp.Regexp, hasValue bool) bool {
+	for _, a := range e.base.Annotations() {
+		if a.Key == key && (!hasValue || p.MatchString(a.Value)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *Entity) matchType(p *regexp.Regexp) bool {
+	var t types.Type
+	switch d := e.Decl.(type) {
+	case *types.Variable:
+		t = d.Type
+	case *types.StructField:
+		t = d.Type
+	case *types.FileType:
+		t = d.Type
+	}
+	return t != nil && p.MatchString(printer.TypeString(t))
+}
+
+// Pointer receivers match patterns with and without `*`.
+func (e *Entity) matchReceiver(p *regexp.Regexp) bool {
+	switch d := e.Decl.(type) {
+	case *types.Method:
+		recv := printer.TypeString(d.Receiver.Type)
+		return p.MatchString(recv) || p.MatchString(strings.TrimLeft(recv, "*"))
+	case *types.Function:
+		return e.Owner != "" && p.MatchString(e.Owner)
+	}
+	return false
+}
+
+func (e *Entity) matchTag(key string, p *regexp.Regexp, hasValue bool) bool {
+	match := func(f *types.StructField) bool {
+		values, ok := f.Tags[key]
+		return ok && (!hasValue || p.MatchString(strings.Join(values, ",")))
+	}
+	switch d := e.Decl.(type) {
+	case *types.StructField:
+		return match(d)
+	case *types.Struct:
+		for i := range d.Fields {
+			if match(&d.Fields[i]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (e *Entity) function() *types.Function {
+	switch d := e.Decl.(type) {
+	case *types.Function:
+		return d
+	case *types.Method:
+		return &d.Function
+	}
+	return nil
+}
+
+// Matches any param, when indexed is false.
+func (e *Entity) matchParam(results bool, i int, indexed bool, p *regexp.Regexp) bool {
+	fn := e.function()
+	if fn == nil {
+		return false
+	}
+	params := fn.Args
+	if results {
+		params = fn.Results
+	}
+	if !indexed {
+		for _, param := range params {
+			if p.MatchString(printer.TypeString(param.Type)) {
+				return true
+			}
+		}
+		return false
+	}
+	if i < 0 {
+		i += len(params)
+	}
+	return i >= 0 && i < len(params) && p.MatchString(printer.TypeString(params[i].Type))
+}
+
+func (e *Entity) count(what string) (int, bool) {
+	switch what {
+	case "methods":
+		switch d := e.Decl.(type) {
+		case *types.Struct:
+			return len(d.Methods), true
+		case *types.FileType:
+			return len(d.Methods), true
+		case *types.Interface:
+			return len(d.AllMethods(e.scope)), true
+		}
+	case "fields":
+		if s, ok := e.Decl.(*types.Struct); ok {
+			return len(s.Fields), true
+		}
+	case "args", "results":
+		if fn := e.function(); fn != nil {
+			if what == "args" {
+				return len(fn.Args), true
+			}
+			return len(fn.Results), true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,215 @@
+// Package query implements a small filter language over declarations model.
+//
+// Query is a list of terms, separated by spaces. Entity matches query, when it matches all terms.
+// Term may be negated by `!` prefix. Values of terms may be double-quoted to contain spaces.
+// Patterns are globs, where `*` matches any sequence of characters and `?` matches one character.
+//
+//	kind:K          entity kind: const, var, type, interface, struct, func, method or field
+//	name:P          name of entity; pattern with dot, e.g. `User.Get*`, matches `Owner.Name`
+//	type:P          type of constant, variable, field or underlying type of type declaration
+//	arg:P           any argument of function or method has type P
+//	arg[N]:P        N-th argument has type P, negative N counts from the end
+//	result:P        any result has type P
+//	result[N]:P     N-th result has type P
+//	recv:P          receiver type of method or name of interface of interface method
+//	tag:K           field with tag K, or structure with such field
+//	tag:K=P         value of tag K matches P
+//	@K              entity has annotation `// @K ...`
+//	@K=P            value of annotation K matches P
+//	exported        entity is exported
+//	methods>N       number of methods, including methods of embedded interfaces; also >=, <, <=, = and != are supported
+//	fields>N        number of fields of structure
+//	args>N          number of arguments
+//	results>N       number of results
+//
+// Example: `kind:method arg[0]:context.Context result[-1]:error`.
+package query
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/vetcher/godecl/types"
+)
+
+// Query is a parsed query.
+type Query struct {
+	// Loads packages of embedded interfaces, which are declared in other packages, may be nil.
+	Importer types.Importer
+
+	source string
+	terms  []term
+}
+
+type term struct {
+	negate bool
+	match  func(e *Entity) bool
+}
+
+func (q *Query) String() string {
+	return q.source
+}
+
+// Reports whether entity matches all terms of query.
+func (q *Query) Match(e *Entity) bool {
+	for _, t := range q.terms {
+		if t.match(e) == t.negate {
+			return false
+		}
+	}
+	return true
+}
+
+var (
+	countTerm = regexp.MustCompile(`^(methods|fields|args|results)(>=|<=|!=|>|<|=)(\d+)$`)
+	indexTerm = regexp.MustCompile(`^(arg|result)\[(-?\d+)\]$`)
+)
+
+// Parses query.
+func Parse(s string) (*Query, error) {
+	words, err := split(s)
+	if err != nil {
+		return nil, err
+	}
+	q := &Query{source: s}
+	for _, w := range words {
+		t := term{}
+		if strings.HasPrefix(w, "!") {
+			t.negate, w = true, w[1:]
+		}
+		if t.match, err = parseTerm(w); err != nil {
+			return nil, err
+		}
+		q.terms = append(q.terms, t)
+	}
+	return q, nil
+}
+
+func parseTerm(w string) (func(e *Entity) bool, error) {
+	if w == "exported" {
+		return (*Entity).exported, nil
+	}
+	if strings.HasPrefix(w, "@") {
+		key, value, hasValue := cut(w[1:], "=")
+		if key == "" {
+			return nil, fmt.Errorf("annotation name is empty in %q", w)
+		}
+		p := glob(value)
+		return func(e *Entity) bool { return e.annotated(key, p, hasValue) }, nil
+	}
+	if m := countTerm.FindStringSubmatch(w); m != nil {
+		n, _ := strconv.Atoi(m[3])
+		what, op := m[1], m[2]
+		return func(e *Entity) bool {
+			count, ok := e.count(what)
+			return ok && compare(count, op, n)
+		}, nil
+	}
+	key, value, ok := cut(w, ":")
+	if !ok {
+		return nil, fmt.Errorf("unexpected term %q", w)
+	}
+	p := glob(value)
+	switch key {
+	case "kind":
+		if !kinds[value] {
+			return nil, fmt.Errorf("unknown kind %q", value)
+		}
+		return func(e *Entity) bool { return e.Kind == value }, nil
+	case "name":
+		return func(e *Entity) bool { return e.matchName(p, strings.Contains(value, ".")) }, nil
+	case "type":
+		return func(e *Entity) bool { return e.matchType(p) }, nil
+	case "recv":
+		return func(e *Entity) bool { return e.matchReceiver(p) }, nil
+	case "tag":
+		tag, tagValue, hasValue := cut(value, "=")
+		tp := glob(tagValue)
+		return func(e *Entity) bool { return e.matchTag(tag, tp, hasValue) }, nil
+	case "arg", "result":
+		return func(e *Entity) bool { return e.matchParam(key == "result", 0, false, p) }, nil
+	}
+	if m := indexTerm.FindStringSubmatch(key); m != nil {
+		i, _ := strconv.Atoi(m[2])
+		results := m[1] == "result"
+		return func(e *Entity) bool { return e.matchParam(results, i, true, p) }, nil
+	}
+	return nil, fmt.Errorf("unknown term %q", key)
+}
+
+// Splits query by spaces, which are not quoted. Quotes are removed.
+func split(s string) ([]string, error) {
+	var (
+		words  []string
+		word   strings.Builder
+		quoted bool
+		inWord bool
+	)
+	for _, r := range s {
+		switch {
+		case r == '"':
+			quoted, inWord = !quoted, true
+		case !quoted && (r == ' ' || r == '\t' || r == '\n'):
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quoted {
+		return nil, fmt.Errorf("unterminated quote in query %q", s)
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+func cut(s, sep string) (before, after string, found bool) {
+	if i := strings.Index(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
+}
+
+// Returns regexp of glob pattern: only `*` and `?` are special, so brackets of types are matched literally.
+func glob(pattern string) *regexp.Regexp {
+	var re strings.Builder
+	re.WriteString("^")
+	for _, r := range pattern {
+		switch r {
+		case '*':
+			re.WriteString(".*")
+		case '?':
+			re.WriteString(".")
+		default:
+			re.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	re.WriteString("$")
+	return regexp.MustCompile(re.String())
+}
+
+func compare(a int, op string, b int) bool {
+	switch op {
+	case ">":
+		return a > b
+	case ">=":
+		return a >= b
+	case "<":
+		return a < b
+	case "<=":
+		return a <= b
+	case "=":
+		return a == b
+	case "!=":
+		return a != b
+	}
+	return false
+}
@@ -0,0 +1,119 @@
+package test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/vetcher/godecl/apidiff"
+	"github.com/vetcher/godecl/types"
+)
+
+func TestAPIDiff(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		old, new     string
+		incompatible []string
+		compatible   []string
+	}{
+		{
+			name: "renamed params and results",
+			old:  "func F(a int) (n int) { return 0 }",
+			new:  "func F(b int) (m int) { return 0 }",
+
+			compatible: []string{"F"},
+		},
+		{
+			name: "renamed params of interface method",
+			old:  "type I interface { M(x int) }",
+			new:  "type I interface { M(y int) }",
+
+			compatible: []string{"I.M"},
+		},
+		{
+			name: "named anonymous param",
+			old:  "type T struct{}\nfunc (T) M(int) {}",
+			new:  "type T struct{}\nfunc (t T) M(n int) {}",
+
+			compatible: []string{"T.M"},
+		},
+		{
+			name: "changed type of param",
+			old:  "func F(a int) {}",
+			new:  "func F(a string) {}",
+
+			incompatible: []string{"F"},
+		},
+		{
+			name: "renamed and changed params",
+			old:  "func F(a int, b int) {}",
+			new:  "func F(c int, b string) {}",
+
+			incompatible: []string{"F"},
+		},
+		{
+			name: "pointer receiver changed to value",
+			old:  "type T struct{}\nfunc (t *T) M(a int) {}",
+			new:  "type T struct{}\nfunc (t T) M(b int) {}",
+
+			compatible: []string{"T.M"},
+		},
+		{
+			name: "value receiver changed to pointer",
+			old:  "type T struct{}\nfunc (t T) M() {}",
+			new:  "type T struct{}\nfunc (t *T) M() {}",
+
+			incompatible: []string{"T.M"},
+		},
+		{
+			name: "added interface method",
+			old:  "type I interface { M() }",
+			new:  "type I interface { M(); N() }",
+
+			incompatible: []string{"I.N"},
+		},
+		{
+			name: "fields and tags",
+			old:  "type S struct { A int `json:\"a\"`; B int; c int }",
+			new:  "type S struct { A int `json:\"aa\"`; C int; c string }",
+
+			incompatible: []string{"S.B"},
+			compatible:   []string{"S.A", "S.C"},
+		},
+		{
+			name: "variables",
+			old:  "var A = f()\nvar B = 1\nvar C int = 1\nvar D = 1\nfunc f() int { return 0 }",
+			new:  "var A = g()\nvar B = 2\nvar C int = 2\nvar D = \"d\"\nfunc f() int { return 0 }\nfunc g() int { return 0 }",
+
+			incompatible: []string{"D"},
+			compatible:   []string{"A", "B", "C"},
+		},
+		{
+			name: "removed and added functions",
+			old:  "func F() {}\nfunc f() {}",
+			new:  "func G() {}",
+
+			incompatible: []string{"F"},
+			compatible:   []string{"G"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			old := parseSource(t, "package a\n"+tc.old)
+			new := parseSource(t, "package a\n"+tc.new)
+			r := apidiff.Compare([]*types.File{old}, []*types.File{new})
+			if got := findingNames(r.Incompatible); !reflect.DeepEqual(got, tc.incompatible) {
+				t.Errorf("incompatible: %v, expected %v", r.Incompatible, tc.incompatible)
+			}
+			if got := findingNames(r.Compatible); !reflect.DeepEqual(got, tc.compatible) {
+				t.Errorf("compatible: %v, expected %v", r.Compatible, tc.compatible)
+			}
+		})
+	}
+}
+
+func findingNames(findings []apidiff.Finding) []string {
+	var names []string
+	for _, f := range findings {
+		names = append(names, f.Name)
+	}
+	return names
+}
@@ -0,0 +1,148 @@
+package test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/vetcher/godecl/diff"
+	"github.com/vetcher/godecl/types"
+)
+
+// Returns changes as lines `kind entity path: old -> new`, where path contains names of parent changes.
+func flattenChanges(changes []diff.Change, parent string) []string {
+	var lines []string
+	for _, c := range changes {
+		path := c.Name
+		if parent != "" {
+			path = parent + "/" + c.Name
+		}
+		line := string(c.Kind) + " " + c.Entity + " " + path
+		if c.Old != "" || c.New != "" {
+			line += ": " + c.Old + " -> " + c.New
+		}
+		lines = append(lines, line)
+		lines = append(lines, flattenChanges(c.Changes, path)...)
+	}
+	return lines
+}
+
+func TestDiffFiles(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		old, new string
+		changes  []string
+	}{
+		{
+			name: "no changes",
+			old:  "const C = 1\nvar V int\nfunc F(a int) error { return nil }",
+			new:  "const C = 1\nvar V int\nfunc F(a int) error { return nil }",
+		},
+		{
+			name: "added and removed functions",
+			old:  "func F() {}\nfunc G() {}",
+			new:  "func G() {}\nfunc H() {}",
+			changes: []string{
+				"removed function F: func F() -> ",
+				"added function H:  -> func H()",
+			},
+		},
+		{
+			name: "params and results",
+			old:  "func F(a int, b string) error { return nil }",
+			new:  "func F(c int, b []byte, d bool) (int, error) { return 0, nil }",
+			changes: []string{
+				"modified function F: func F(a int, b string) error -> func F(c int, b []byte, d bool) (int, error)",
+				"renamed param F/c: a int -> c int",
+				"modified param F/b: b string -> b []byte",
+				"added param F/d:  -> d bool",
+				"modified result F/#0: error -> int",
+				"added result F/#1:  -> error",
+			},
+		},
+		{
+			name: "methods and receivers",
+			old:  "type T struct{}\nfunc (t T) M() {}\nfunc (t T) N() {}",
+			new:  "type T struct{}\nfunc (t *T) M() {}",
+			changes: []string{
+				"modified method T.M: func (t T) M() -> func (t *T) M()",
+				"modified receiver T.M/t: T -> *T",
+				"removed method T.N: func (t T) N() -> ",
+			},
+		},
+		{
+			name: "fields and tags",
+			old:  "type S struct { A int `json:\"a\" xml:\"a\"`; B string; C int }",
+			new:  "type S struct { A int `json:\"aa\" db:\"a\"`; B []string; D int }",
+			changes: []string{
+				"modified struct S",
+				"modified field S/A",
+				"added tag S/A/db:  -> a",
+				"modified tag S/A/json: a -> aa",
+				"removed tag S/A/xml: a -> ",
+				"modified field S/B: string -> []string",
+				"removed field S/C: int -> ",
+				"added field S/D:  -> int",
+			},
+		},
+		{
+			name: "interface methods",
+			old:  "type R interface{}\ntype I interface { M(a int); N() }",
+			new:  "type R interface{}\ntype I interface { R; M(b string); O() error }",
+			changes: []string{
+				"modified interface I",
+				"added embedded I/R:  -> R",
+				"modified method I/M: func M(a int) -> func M(b string)",
+				"modified param I/M/b: a int -> b string",
+				"removed method I/N: func N() -> ",
+				"added method I/O:  -> func O() error",
+			},
+		},
+		{
+			name: "constants, variables and types",
+			old:  "const C = 1\nvar V int\ntype T int",
+			new:  "const C = 2\nvar V string\ntype T string",
+			changes: []string{
+				"modified const C: INT = 1 -> INT = 2",
+				"modified var V: int -> string",
+				"modified type T: int -> string",
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			old := parseSource(t, "package a\n"+tc.old)
+			new := parseSource(t, "package a\n"+tc.new)
+			cs := diff.Files(old, new)
+			if got := flattenChanges(cs.Changes, ""); !reflect.DeepEqual(got, tc.changes) {
+				t.Errorf("changes:\n%q\nexpected:\n%q", got, tc.changes)
+			}
+			if cs.Empty() != (len(tc.changes) == 0) {
+				t.Errorf("Empty() is %v", cs.Empty())
+			}
+		})
+	}
+}
+
+func TestDiffPackages(t *testing.T) {
+	old := []*types.File{
+		parseSource(t, "package a\nfunc F() {}"),
+		parseSource(t, "package a\ntype T struct{}\nfunc (T) M() {}"),
+	}
+	// Declarations are moved between files.
+	new := []*types.File{
+		parseSource(t, "package a\nfunc F() {}\ntype T struct{}"),
+		parseSource(t, "package a\nfunc (T) M() {}\nfunc (T) N() {}"),
+	}
+	cs := diff.Packages(old, new)
+	data, err := json.Marshal(cs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"changes":[{"kind":"added","entity":"method","name":"T.N","new":"func (T) N()"}]}`
+	if string(data) != expected {
+		t.Errorf("changeset %s, expected %s", data, expected)
+	}
+	if data, _ := json.Marshal(diff.Packages(old, old)); string(data) != "{}" {
+		t.Errorf("empty changeset %s", data)
+	}
+}
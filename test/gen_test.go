@@ -0,0 +1,645 @@
+package test
+
+import (
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	gotypes "go/types"
+	"strings"
+	"testing"
+
+	"github.com/vetcher/godecl/gen/decorator"
+	"github.com/vetcher/godecl/gen/impl"
+	"github.com/vetcher/godecl/gen/jsonschema"
+	"github.com/vetcher/godecl/gen/mock"
+	"github.com/vetcher/godecl/gen/openapi"
+	"github.com/vetcher/godecl/gen/proto"
+	"github.com/vetcher/godecl/gen/tmpl"
+	"github.com/vetcher/godecl/types"
+)
+
+const genSource = `package a
+
+import (
+	"context"
+	"io"
+)
+
+type Closer interface {
+	Close() error
+}
+
+type Service interface {
+	Closer
+	Get(ctx context.Context, id int) (*User, error)
+	Find(_ context.Context, names ...string) (users []User, total int, err error)
+	Write(io.Writer, []byte)
+	Reset()
+}
+
+type User struct {
+	Name string
+}
+`
+
+// Type checks sources of package a together.
+func typeCheck(t *testing.T, sources ...string) {
+	typeCheckWith(t, nil, sources...)
+}
+
+// Type checks sources of package a, which may import packages of deps, keyed by import paths.
+func typeCheckWith(t *testing.T, deps map[string]string, sources ...string) {
+	fset := token.NewFileSet()
+	imp := &sourceImporter{fset: fset, deps: deps, std: importer.ForCompiler(fset, "source", nil), checked: make(map[string]*gotypes.Package)}
+	if _, err := imp.check("a", sources); err != nil {
+		t.Fatalf("%v\n%s", err, sources[len(sources)-1])
+	}
+}
+
+// Imports packages from sources of deps or standard library.
+type sourceImporter struct {
+	fset    *token.FileSet
+	deps    map[string]string
+	std     gotypes.Importer
+	checked map[string]*gotypes.Package
+}
+
+func (imp *sourceImporter) Import(path string) (*gotypes.Package, error) {
+	if pkg, ok := imp.checked[path]; ok {
+		return pkg, nil
+	}
+	src, ok := imp.deps[path]
+	if !ok {
+		return imp.std.Import(path)
+	}
+	pkg, err := imp.check(path, []string{src})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	imp.checked[path] = pkg
+	return pkg, nil
+}
+
+func (imp *sourceImporter) check(path string, sources []string) (*gotypes.Package, error) {
+	var files []*ast.File
+	for i, src := range sources {
+		f, err := parser.ParseFile(imp.fset, "", src, 0)
+		if err != nil {
+			return nil, fmt.Errorf("source %d: %v", i, err)
+		}
+		files = append(files, f)
+	}
+	conf := gotypes.Config{Importer: imp}
+	return conf.Check(path, imp.fset, files, nil)
+}
+
+func TestMockGenerator(t *testing.T) {
+	file := parseSource(t, genSource)
+	pkg := &types.Package{Path: "a", Files: []*types.File{file}}
+	iface := &file.Interfaces[1]
+	src, err := mock.Generate(mock.Config{Package: "a", PackagePath: "a", SourcePath: "a", Resolver: pkg.Scope(nil)}, iface)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{
+		"type ServiceMock struct",
+		"func (m *ServiceMock) Close() (r0 error)",
+		"func (m *ServiceMock) Find(p0 context.Context, names ...string) (users []User, total int, err error)",
+		"func (e *ServiceMockFindExpectation) WithArgs(p0 context.Context, names ...string)",
+		"func (m *ServiceMock) Write(p0 io.Writer, p1 []byte)",
+		"func (m *ServiceMock) Verify() error",
+	} {
+		if !strings.Contains(string(src), s) {
+			t.Errorf("generated mock does not contain %q\n%s", s, src)
+		}
+	}
+	typeCheck(t, genSource, string(src))
+}
+
+func TestMockGeneratorNames(t *testing.T) {
+	const source = `package a
+
+type Checker interface {
+	Check(expected, got string) error
+}
+
+type Verifier interface {
+	Get() error
+	GetCalls() int
+}
+`
+	file := parseSource(t, source)
+	pkg := &types.Package{Path: "a", Files: []*types.File{file}}
+	cfg := mock.Config{Package: "a", PackagePath: "a", SourcePath: "a", Resolver: pkg.Scope(nil)}
+	src, err := mock.Generate(cfg, &file.Interfaces[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	typeCheck(t, source, string(src))
+	if _, err := mock.Generate(cfg, &file.Interfaces[1]); err == nil {
+		t.Errorf("conflict of method GetCalls with mock helper is not reported")
+	}
+}
+
+func TestMockGeneratorPromotedMethods(t *testing.T) {
+	imp := packages{
+		"example.com/other": {Path: "example.com/other", Files: []*types.File{parseSource(t, otherSource)}},
+	}
+	file := parseSource(t, storeSource)
+	pkg := &types.Package{Path: "example.com/store", Files: []*types.File{file}}
+	cfg := mock.Config{Package: "a", PackagePath: "a", SourcePath: pkg.Path, Resolver: pkg.Scope(imp)}
+	src, err := mock.Generate(cfg, &file.Interfaces[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{
+		"GetFunc func(id int) (*other.Item, error)",
+		"func (m *StoreMock) Get(id int) (r0 *other.Item, r1 error)",
+	} {
+		if !strings.Contains(string(src), s) {
+			t.Errorf("generated mock does not contain %q\n%s", s, src)
+		}
+	}
+	typeCheckWith(t, map[string]string{"example.com/other": otherSource, "example.com/store": storeSource}, string(src))
+}
+
+func TestDecoratorGenerator(t *testing.T) {
+	file := parseSource(t, genSource)
+	pkg := &types.Package{Path: "a", Files: []*types.File{file}}
+	iface := &file.Interfaces[1]
+	src, err := decorator.Generate(decorator.Config{Package: "a", PackagePath: "a", SourcePath: "a", Resolver: pkg.Scope(nil)}, iface)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{
+		"func (d *ServiceDecorator) Get(ctx context.Context, id int) (r0 *User, err error)",
+		"args := []interface{}{id}",
+		`d.After(ctx, "Get", args, []interface{}{r0}, err)`,
+		"func (d *ServiceDecorator) Find(ctx context.Context, names ...string) (users []User, total int, err error)",
+		"return d.Next.Find(ctx, names...)",
+		"func (d *ServiceDecorator) Reset() {\n\tctx := context.Background()",
+		`d.After(ctx, "Reset", args, []interface{}{}, nil)`,
+	} {
+		if !strings.Contains(string(src), s) {
+			t.Errorf("generated decorator does not contain %q\n%s", s, src)
+		}
+	}
+	typeCheck(t, genSource, string(src))
+}
+
+func TestDecoratorGeneratorNames(t *testing.T) {
+	const source = `package a
+
+type Iterator interface {
+	Next() bool
+	Value() int
+}
+
+type Values interface {
+	Iterator
+	Reset()
+}
+`
+	file := parseSource(t, source)
+	pkg := &types.Package{Path: "a", Files: []*types.File{file}}
+	cfg := decorator.Config{Package: "a", PackagePath: "a", SourcePath: "a", Resolver: pkg.Scope(nil)}
+	for i := range file.Interfaces {
+		if _, err := decorator.Generate(cfg, &file.Interfaces[i]); err == nil {
+			t.Errorf("conflict of method Next of %s with field of decorator is not reported", file.Interfaces[i].Name)
+		}
+	}
+
+	imp := packages{
+		"example.com/other": {Path: "example.com/other", Files: []*types.File{parseSource(t, otherSource)}},
+	}
+	file = parseSource(t, storeSource)
+	pkg = &types.Package{Path: "example.com/store", Files: []*types.File{file}}
+	cfg = decorator.Config{Package: "a", PackagePath: "a", SourcePath: pkg.Path, Resolver: pkg.Scope(imp)}
+	src, err := decorator.Generate(cfg, &file.Interfaces[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := "func (d *StoreDecorator) Get(id int) (r0 *other.Item, err error)"; !strings.Contains(string(src), s) {
+		t.Errorf("generated decorator does not contain %q\n%s", s, src)
+	}
+	typeCheckWith(t, map[string]string{"example.com/other": otherSource, "example.com/store": storeSource}, string(src))
+}
+
+const genTemplate = `{{range interfaces .Package}}{{if hasAnnotation . "logged"}}
+type logged{{.Name}} struct {
+	next {{.Name}}
+	log  func(string)
+}
+{{$iface := .}}{{range methods .}}{{$args := named .Args "p"}}
+func (l logged{{$iface.Name}}) {{.Name}}({{params $args}}){{results .Results}} {
+	l.log({{import "fmt"}}.Sprint("{{snake .Name}}", {{range $args}}{{if not (isContext .Type)}}{{.Name}}, {{end}}{{end}}))
+	{{if .Results}}return {{end}}l.next.{{.Name}}({{args $args}})
+}
+{{end}}{{end}}{{end}}
+{{range structs .Package}}
+var {{camel .Name}}Columns = []string{ {{range .Fields}}"{{tag . "db"}}", {{end}} }
+{{end}}`
+
+const genTemplateSource = `package a
+
+import "context"
+
+// @logged
+type UserStore interface {
+	GetUser(ctx context.Context, id int) (*UserRow, error)
+	Delete(context.Context, ...int)
+}
+
+type UserRow struct {
+	ID   int    ` + "`db:\"id\"`" + `
+	Name string ` + "`db:\"user_name,omitempty\"`" + `
+}
+`
+
+func TestTemplateGenerator(t *testing.T) {
+	file := parseSource(t, genTemplateSource)
+	pkg := &types.Package{Path: "a", Files: []*types.File{file}}
+	src, err := tmpl.Generate(tmpl.Config{Package: "a", PackagePath: "a", SourcePath: "a", Resolver: pkg.Scope(nil)}, "test", genTemplate, pkg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{
+		"func (l loggedUserStore) GetUser(ctx context.Context, id int) (*UserRow, error)",
+		`l.log(fmt.Sprint("get_user", id))`,
+		"l.next.Delete(p0, p1...)",
+		`var userRowColumns = []string{"id", "user_name"}`,
+	} {
+		if !strings.Contains(string(src), s) {
+			t.Errorf("generated file does not contain %q\n%s", s, src)
+		}
+	}
+	typeCheck(t, genTemplateSource, string(src))
+}
+
+func TestImplGenerator(t *testing.T) {
+	file := parseSource(t, genSource)
+	pkg := &types.Package{Path: "a", Files: []*types.File{file}}
+	iface := &file.Interfaces[1]
+	src, err := impl.Generate(impl.Config{Package: "a", PackagePath: "a", SourcePath: "a", Resolver: pkg.Scope(nil)}, "*backend", iface)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{
+		"func (b *backend) Get(ctx context.Context, id int) (*User, error) {\n\treturn nil, nil\n}",
+		"func (b *backend) Find(_ context.Context, names ...string) (users []User, total int, err error) {\n\treturn nil, 0, nil\n}",
+		"func (b *backend) Write(io.Writer, []byte) {\n}",
+		"func (b *backend) Close() error {",
+	} {
+		if !strings.Contains(string(src), s) {
+			t.Errorf("generated stubs do not contain %q\n%s", s, src)
+		}
+	}
+	typeCheck(t, genSource, "package a\n\ntype backend struct{}\n\nvar _ Service = (*backend)(nil)\n", string(src))
+}
+
+func TestImplGeneratorPromotedMethods(t *testing.T) {
+	imp := packages{
+		"example.com/other": {Path: "example.com/other", Files: []*types.File{parseSource(t, otherSource)}},
+	}
+	file := parseSource(t, storeSource)
+	pkg := &types.Package{Path: "example.com/store", Files: []*types.File{file}}
+	cfg := impl.Config{Package: "a", PackagePath: "a", SourcePath: pkg.Path, Resolver: pkg.Scope(imp)}
+	src, err := impl.Generate(cfg, "d *DB", &file.Interfaces[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := "func (d *DB) Get(id int) (*other.Item, error) {\n\treturn nil, nil\n}"; !strings.Contains(string(src), s) {
+		t.Errorf("generated stubs do not contain %q\n%s", s, src)
+	}
+	deps := map[string]string{"example.com/other": otherSource, "example.com/store": storeSource}
+	typeCheckWith(t, deps, "package a\n\nimport \"example.com/store\"\n\ntype DB struct{}\n\nvar _ store.Store = (*DB)(nil)\n", string(src))
+}
+
+const protoSource = `package a
+
+import (
+	"context"
+	"time"
+)
+
+// Users manages users.
+type Users interface {
+	// Get returns user by id.
+	Get(ctx context.Context, req *GetRequest) (*User, error)
+	Delete(ctx context.Context, id int64, hard bool) error
+}
+
+type GetRequest struct {
+	ID int64 ` + "`json:\"id\"`" + `
+}
+
+type Status string
+
+type Meta struct {
+	Created time.Time
+}
+
+// User is a user.
+type User struct {
+	Meta
+	// Name of user.
+	Name     string
+	Nick     *string
+	Tags     []Status
+	Scores   map[string]float64
+	Friends  []*User
+	Avatar   []byte
+	Internal string ` + "`proto:\"-\"`" + `
+	Version  int ` + "`proto:\"10\"`" + `
+	secret   string
+}
+`
+
+const protoExpected = `// Code generated by godecl. DO NOT EDIT.
+
+syntax = "proto3";
+
+package users;
+
+import "google/protobuf/empty.proto";
+import "google/protobuf/timestamp.proto";
+
+// Users manages users.
+service Users {
+  // Get returns user by id.
+  rpc Get(GetRequest) returns (User);
+  rpc Delete(UsersDeleteRequest) returns (google.protobuf.Empty);
+}
+
+message GetRequest {
+  int64 id = 1;
+}
+
+// User is a user.
+message User {
+  google.protobuf.Timestamp created = 1;
+  // Name of user.
+  string name = 2;
+  optional string nick = 11;
+  repeated string tags = 4;
+  map<string, double> scores = 12;
+  repeated User friends = 13;
+  bytes avatar = 14;
+  int64 version = 10;
+  reserved 3;
+}
+
+message UsersDeleteRequest {
+  int64 id = 1;
+  bool hard = 2;
+}
+`
+
+func TestProtoGenerator(t *testing.T) {
+	file := parseSource(t, protoSource)
+	pkg := &types.Package{Path: "a", Files: []*types.File{file}}
+	lock := &proto.Lock{Messages: map[string]map[string]int{
+		"User": {"created": 1, "name": 2, "email": 3, "tags": 4},
+	}}
+	cfg := proto.Config{Package: "users", SourcePath: "a", Resolver: pkg.Scope(nil), Lock: lock}
+	src, err := proto.Generate(cfg, []*types.Interface{&file.Interfaces[0]}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(src) != protoExpected {
+		t.Errorf("generated proto:\n%s\nexpected:\n%s", src, protoExpected)
+	}
+	if n := lock.Messages["User"]["email"]; n != 3 {
+		t.Errorf("removed field should be kept in lock, got number %d", n)
+	}
+}
+
+func TestProtoGeneratorMessageNames(t *testing.T) {
+	const source = `package a
+
+type Users interface {
+	Get(id int) error
+}
+
+type Orders interface {
+	Get(orderID string, limit int) error
+}
+
+type Conflict interface {
+	Get(a, b int) error
+}
+
+type ConflictGetRequest struct {
+	ID int
+}
+`
+	file := parseSource(t, source)
+	pkg := &types.Package{Path: "a", Files: []*types.File{file}}
+	cfg := proto.Config{Package: "a", SourcePath: "a", Resolver: pkg.Scope(nil)}
+	src, err := proto.Generate(cfg, []*types.Interface{&file.Interfaces[0], &file.Interfaces[1]}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{
+		"rpc Get(UsersGetRequest) returns (google.protobuf.Empty);",
+		"rpc Get(OrdersGetRequest) returns (google.protobuf.Empty);",
+		"message UsersGetRequest {\n  int64 id = 1;\n}",
+		"message OrdersGetRequest {\n  string order_id = 1;\n  int64 limit = 2;\n}",
+	} {
+		if !strings.Contains(string(src), s) {
+			t.Errorf("generated proto does not contain %q\n%s", s, src)
+		}
+	}
+	_, err = proto.Generate(cfg, []*types.Interface{&file.Interfaces[2]}, []*types.Struct{&file.Structures[0]})
+	if err == nil {
+		t.Errorf("conflict of generated message with structure is not reported")
+	}
+}
+
+const openapiSource = `package a
+
+import (
+	"context"
+	"time"
+)
+
+type Users interface {
+	// Get returns user.
+	// It fails, when user is not found.
+	// @route GET /users/{id}
+	Get(ctx context.Context, id int64) (*User, error)
+	// @route GET /users
+	// @tag admin
+	List(ctx context.Context, filter Filter) ([]User, error)
+	// @route POST /users
+	// @success 201
+	Create(ctx context.Context, user User) error
+	// Not exposed over HTTP.
+	Close() error
+}
+
+type Filter struct {
+	Name  string ` + "`json:\"name,omitempty\" validate:\"max=64\"`" + `
+	Limit int    ` + "`json:\"limit\" validate:\"gte=1,lte=100\"`" + `
+}
+
+type Base struct {
+	ID      int64     ` + "`json:\"id\"`" + `
+	Created time.Time ` + "`json:\"created\"`" + `
+}
+
+// Role of user.
+type Role string
+
+// User is an account.
+type User struct {
+	Base
+	// Email of user.
+	Email   string            ` + "`json:\"email\" validate:\"required,email\"`" + `
+	Role    Role              ` + "`json:\"role\" validate:\"oneof=admin user\"`" + `
+	Manager *User             ` + "`json:\"manager,omitempty\"`" + `
+	Labels  map[string]string ` + "`json:\"labels,omitempty\"`" + `
+	Secret  string            ` + "`json:\"-\"`" + `
+}
+`
+
+func TestOpenAPIGenerator(t *testing.T) {
+	file := parseSource(t, openapiSource)
+	pkg := &types.Package{Path: "a", Files: []*types.File{file}}
+	cfg := openapi.Config{Title: "Users", Version: "1.0", SourcePath: "a", Resolver: pkg.Scope(nil)}
+	doc, err := openapi.Generate(cfg, []*types.Interface{&file.Interfaces[0]}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := doc.YAML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{
+		"  \"/users/{id}\":\n    get:\n      tags:\n      - Users\n      summary: Get returns user.\n",
+		"      operationId: UsersGet\n",
+		"      operationId: UsersList\n",
+		"      - name: limit\n        in: query\n        required: true\n        schema:\n          type: integer\n          format: int64\n          minimum: 1\n          maximum: 100\n",
+		"      responses:\n        \"201\":\n          description: Success\n",
+		"      requestBody:\n        required: true\n        content:\n          application/json:\n            schema:\n              \"$ref\": \"#/components/schemas/User\"\n",
+		"        created:\n          type: string\n          format: date-time\n",
+		"        role:\n          type: string\n          description: Role of user.\n          enum:\n          - admin\n          - user\n",
+		"        manager:\n          allOf:\n          - \"$ref\": \"#/components/schemas/User\"\n          nullable: true\n",
+		"      required:\n      - id\n      - created\n      - email\n      - role\n",
+	} {
+		if !strings.Contains(string(src), s) {
+			t.Errorf("generated document does not contain %q\n%s", s, src)
+		}
+	}
+	if strings.Contains(string(src), "Close") || strings.Contains(string(src), "secret") {
+		t.Errorf("document contains not annotated method or skipped field\n%s", src)
+	}
+}
+
+const jsonschemaSource = `package a
+
+// Mode of server.
+type Mode int
+
+const (
+	Off Mode = iota
+	On
+	Auto
+)
+
+type Endpoint struct {
+	Host string ` + "`json:\"host\"`" + `
+	Port int    ` + "`json:\"port,omitempty\"`" + `
+}
+
+// TLS settings.
+type TLS struct {
+	Cert string ` + "`json:\"cert\"`" + `
+}
+
+// Config of server.
+type Config struct {
+	// Public endpoint.
+	Public  Endpoint  ` + "`json:\"public\"`" + `
+	Private *Endpoint ` + "`json:\"private\"`" + `
+	TLS     TLS       ` + "`json:\"tls\"`" + `
+	Mode    Mode      ` + "`json:\"mode\"`" + `
+}
+`
+
+const jsonschemaExpected = `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "title": "Config",
+  "description": "Config of server.",
+  "type": "object",
+  "properties": {
+    "public": {
+      "$ref": "#/$defs/Endpoint",
+      "description": "Public endpoint."
+    },
+    "private": {
+      "$ref": "#/$defs/Endpoint"
+    },
+    "tls": {
+      "description": "TLS settings.",
+      "type": "object",
+      "properties": {
+        "cert": {
+          "type": "string"
+        }
+      },
+      "required": [
+        "cert"
+      ]
+    },
+    "mode": {
+      "description": "Mode of server.",
+      "type": "integer",
+      "enum": [
+        0,
+        1,
+        2
+      ]
+    }
+  },
+  "required": [
+    "public",
+    "tls",
+    "mode"
+  ],
+  "$defs": {
+    "Endpoint": {
+      "type": "object",
+      "properties": {
+        "host": {
+          "type": "string"
+        },
+        "port": {
+          "type": "integer"
+        }
+      },
+      "required": [
+        "host"
+      ]
+    }
+  }
+}
+`
+
+func TestJSONSchemaGenerator(t *testing.T) {
+	file := parseSource(t, jsonschemaSource)
+	pkg := &types.Package{Path: "a", Files: []*types.File{file}}
+	schema, err := jsonschema.Generate(jsonschema.Config{Package: pkg}, &file.Structures[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := schema.JSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(src) != jsonschemaExpected {
+		t.Errorf("generated schema:\n%s\nexpected:\n%s", src, jsonschemaExpected)
+	}
+}
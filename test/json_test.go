@@ -0,0 +1,163 @@
+package test
+
+import (
+	"encoding/json"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"reflect"
+	"testing"
+
+	"github.com/vetcher/godecl"
+	"github.com/vetcher/godecl/internal/yaml"
+	"github.com/vetcher/godecl/types"
+)
+
+const jsonSource = `package a
+
+import (
+	"context"
+	"io"
+)
+
+type S struct {
+	A *int ` + "`json:\"a\"`" + `
+	B []map[string]io.Writer
+	C chan<- error
+	D interface {
+		M(x ...int)
+	}
+	E [3]**S
+}
+
+func (s *S) Do(ctx context.Context, v ...interface{}) (n int, err error) { return }
+
+type T int
+
+func (T) Do() {}
+`
+
+func TestJSONRoundTrip(t *testing.T) {
+	tree, err := parser.ParseFile(token.NewFileSet(), "a.go", jsonSource, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, err := godecl.ParseAstFile(tree, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded types.File
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(file, &decoded) {
+		again, _ := json.Marshal(decoded)
+		t.Errorf("decoded file differs:\n%s\n%s", data, again)
+	}
+	if decoded.Structures[0].Methods[0] != &decoded.Methods[0] {
+		t.Error("structure is not linked with its method")
+	}
+}
+
+func TestSchemaIsUpToDate(t *testing.T) {
+	published, err := ioutil.ReadFile("../types/schema.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema, err := types.JSONSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(published) != string(schema)+"\n" {
+		t.Error("types/schema.json is outdated, run `go generate ./types`")
+	}
+}
+
+func TestYAML(t *testing.T) {
+	src := `{"name":"x","docs":["// a: b"],"empty":{},"list":[{"a":1,"b":[true,null]},[]],"yes":"yes"}`
+	expected := `name: x
+docs:
+- "// a: b"
+empty: {}
+list:
+- a: 1
+  b:
+  - true
+  - null
+- []
+"yes": "yes"
+`
+	data, err := yaml.FromJSON([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != expected {
+		t.Errorf("unexpected yaml:\n%s", data)
+	}
+}
+
+func TestYAMLQuoting(t *testing.T) {
+	for _, tc := range []struct {
+		json, yaml string
+	}{
+		{`"plain text"`, "plain text\n"},
+		{`"path/to.go"`, "path/to.go\n"},
+		{`"x: y"`, "\"x: y\"\n"},
+		{`"No"`, "\"No\"\n"},
+		{`"null"`, "\"null\"\n"},
+		{`"123"`, "\"123\"\n"},
+		{`"1.5"`, "\"1.5\"\n"},
+		{`"trailing "`, "\"trailing \"\n"},
+		{`" leading"`, "\" leading\"\n"},
+		{`""`, "\"\"\n"},
+		{`"-dash"`, "\"-dash\"\n"},
+		{`"#comment"`, "\"#comment\"\n"},
+		{`"*User"`, "\"*User\"\n"},
+		{`"[]int"`, "\"[]int\"\n"},
+		{`"a\nb"`, "\"a\\nb\"\n"},
+		{`42`, "42\n"},
+		{`[]`, "[]\n"},
+		{`{"on":{},"key: colon":1}`, "\"on\": {}\n\"key: colon\": 1\n"},
+	} {
+		data, err := yaml.FromJSON([]byte(tc.json))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != tc.yaml {
+			t.Errorf("yaml of %s:\n%s\nexpected:\n%s", tc.json, data, tc.yaml)
+		}
+	}
+}
+
+func TestYAMLNesting(t *testing.T) {
+	for _, tc := range []struct {
+		json, yaml string
+	}{
+		{`[[1,[2,3]],[[]],[{"a":[{"b":{}}]}]]`, `- - 1
+  - - 2
+    - 3
+- - []
+- - a:
+    - b: {}
+`},
+		{`{"a":{"b":{"c":[1,{"d":[[true]]}]}}}`, `a:
+  b:
+    c:
+    - 1
+    - d:
+      - - true
+`},
+	} {
+		data, err := yaml.FromJSON([]byte(tc.json))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != tc.yaml {
+			t.Errorf("yaml of %s:\n%s\nexpected:\n%s", tc.json, data, tc.yaml)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package test
+
+import (
+	"go/parser"
+	"go/token"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/vetcher/godecl"
+	"github.com/vetcher/godecl/printer"
+	"github.com/vetcher/godecl/types"
+)
+
+const printerSource = `// Package docs
+package a
+
+import (
+	"context"
+	stdio "io"
+)
+
+// Answer docs
+const Answer = 42
+
+var W stdio.Writer
+
+var R, PW = stdio.Pipe()
+
+var PR2, W2 = stdio.Pipe()
+
+type ID int
+
+// Service docs
+type Service interface {
+	// Get docs
+	Get(ctx context.Context, id ID) (*User, error)
+	Watch(chan (<-chan int), ...string)
+}
+
+type User struct {
+	// Name docs
+	Name string ` + "`json:\"name\"`" + `
+	ID
+	Friends map[ID][]*User
+}
+
+func New(w stdio.Writer) Service {
+	panic("not implemented")
+}
+
+func (u *User) Do() {
+	panic("not implemented")
+}
+`
+
+func parseSource(t *testing.T, src string) *types.File {
+	tree, err := parser.ParseFile(token.NewFileSet(), "a.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, err := godecl.ParseAstFile(tree, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestPrinter(t *testing.T) {
+	file := parseSource(t, printerSource)
+	src, err := printer.Source(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(parseSource(t, string(src)), file) {
+		t.Errorf("printed file differs from source:\n%s", src)
+	}
+	if !strings.Contains(string(src), "var R, PW = stdio.Pipe()\n") {
+		t.Errorf("variables, assigned by results of call, are not printed as one declaration:\n%s", src)
+	}
+}
+
+const iotaSource = `package a
+
+import "time"
+
+type ID int
+
+const (
+	A ID = ID(iota)
+	B
+	_
+	C
+)
+
+const (
+	Second = time.Second * iota
+	Minute
+)
+`
+
+func TestPrinterIota(t *testing.T) {
+	file := parseSource(t, iotaSource)
+	src, err := printer.Source(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(parseSource(t, string(src)), file) {
+		t.Errorf("printed file differs from source:\n%s", src)
+	}
+	for _, s := range []string{"const C ID = ID(3)\n", "const Minute = time.Second * 1\n"} {
+		if !strings.Contains(string(src), s) {
+			t.Errorf("%q is not printed:\n%s", s, src)
+		}
+	}
+	typeCheck(t, string(src))
+}
@@ -0,0 +1,79 @@
+package test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/vetcher/godecl/query"
+	"github.com/vetcher/godecl/types"
+)
+
+const querySource = `package a
+
+import "context"
+
+// @route GET /users
+type Service interface {
+	Get(ctx context.Context, id int) (*User, error)
+	List(ctx context.Context) ([]User, error)
+	Close()
+}
+
+type Admin interface {
+	Service
+	Ban(id int) error
+}
+
+type User struct {
+	ID   int    ` + "`db:\"id\" json:\"id\"`" + `
+	Name string ` + "`json:\"name,omitempty\"`" + `
+}
+
+func (u *User) Save(ctx context.Context) error { return nil }
+`
+
+func TestQuery(t *testing.T) {
+	pkg := &types.Package{Path: "a", Files: []*types.File{parseSource(t, querySource)}}
+	cases := map[string][]string{
+		`kind:method arg[0]:context.Context result[-1]:error`: {"a.Service.Get", "a.Service.List", "a.User.Save"},
+		`kind:method !arg:context.Context`:                    {"a.Service.Close", "a.Admin.Ban"},
+		`tag:db`:                                              {"a.User", "a.User.ID"},
+		`kind:field tag:json=*omitempty`:                      {"a.User.Name"},
+		`kind:interface methods>2 @route="GET *"`:             {"a.Service"},
+		`kind:interface methods=4`:                            {"a.Admin"},
+		`name:User.* recv:User`:                               {"a.User.Save"},
+	}
+	for src, expected := range cases {
+		q, err := query.Parse(src)
+		if err != nil {
+			t.Errorf("%s: %v", src, err)
+			continue
+		}
+		var names []string
+		for _, e := range q.Find(pkg) {
+			names = append(names, e.Name)
+		}
+		if !reflect.DeepEqual(names, expected) {
+			t.Errorf("%s: found %v, expected %v", src, names, expected)
+		}
+	}
+
+	// Embedded interfaces of other packages are loaded by importer.
+	store := &types.Package{Path: "example.com/store", Files: []*types.File{parseSource(t, storeSource)}}
+	q, err := query.Parse("kind:interface methods=2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found := q.Find(store); len(found) != 0 {
+		t.Errorf("methods of other package are counted without importer: %v", found)
+	}
+	q.Importer = packages{
+		"example.com/other": {Path: "example.com/other", Files: []*types.File{parseSource(t, otherSource)}},
+	}
+	if found := q.Find(store); len(found) != 1 || found[0].Name != "example.com/store.Store" {
+		t.Errorf("methods of embedded interface of other package are not counted: %v", found)
+	}
+	if _, err := query.Parse("kind:package"); err == nil {
+		t.Error("unknown kind should not be parsed")
+	}
+}
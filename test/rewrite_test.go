@@ -0,0 +1,83 @@
+package test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/vetcher/godecl/printer"
+	"github.com/vetcher/godecl/types"
+)
+
+const rewriteSource = `package a
+
+type User struct {
+	Friends []*User
+	Meta    interface {
+		Owner() *User
+	}
+}
+
+type Users []User
+
+type Service interface {
+	Get(id int) (*User, error)
+}
+
+func (u *User) Copy() User { return *u }
+
+func (Users) Len() int { return 0 }
+`
+
+// Replaces User by DTO.
+func userToDTO(t types.Type) (types.Type, bool) {
+	if name, ok := t.(types.TName); ok && name.TypeName == "User" {
+		return types.TName{TypeName: "DTO"}, true
+	}
+	return nil, false
+}
+
+func TestRewrite(t *testing.T) {
+	file := parseSource(t, rewriteSource)
+	original := parseSource(t, rewriteSource)
+
+	// Types share interface, which should not be changed.
+	meta := file.Structures[0].Fields[1].Type
+	shared := types.TMap{Key: meta, Value: meta}
+	rewritten := types.Rewrite(shared, userToDTO).(types.TMap)
+	owner := func(t types.Type) string {
+		return t.(types.TInterface).Interface.Methods[0].String()
+	}
+	if owner(rewritten.Key) != "func Owner() *DTO" || owner(rewritten.Value) != "func Owner() *DTO" {
+		t.Errorf("rewritten type %s, %s", owner(rewritten.Key), owner(rewritten.Value))
+	}
+	if owner(shared.Key) != "func Owner() *User" {
+		t.Errorf("original type is changed: %s", owner(shared.Key))
+	}
+
+	iface := types.RewriteInterface(&file.Interfaces[0], userToDTO)
+	if s := iface.Methods[0].String(); s != "func Get(id int) (*DTO, error)" {
+		t.Errorf("rewritten interface method %s", s)
+	}
+	rs := types.RewriteStruct(&file.Structures[0], userToDTO)
+	if s := printer.TypeString(rs.Fields[0].Type); s != "[]*DTO" {
+		t.Errorf("rewritten field %s", s)
+	}
+
+	nf := types.RewriteFile(file, userToDTO)
+	if !reflect.DeepEqual(file, original) {
+		t.Errorf("original file is changed")
+	}
+	if s := printer.TypeString(nf.Methods[0].Receiver.Type); s != "*DTO" {
+		t.Errorf("rewritten receiver %s", s)
+	}
+	// Methods of rewritten declarations are methods of rewritten file.
+	if len(nf.Structures[0].Methods) != 1 || nf.Structures[0].Methods[0] != &nf.Methods[0] {
+		t.Errorf("methods of structure are not linked with methods of rewritten file")
+	}
+	if len(nf.Types[0].Methods) != 1 || nf.Types[0].Methods[0] != &nf.Methods[1] {
+		t.Errorf("methods of type are not linked with methods of rewritten file")
+	}
+	if file.Structures[0].Methods[0] != &file.Methods[0] {
+		t.Errorf("methods of original structure are relinked")
+	}
+}
@@ -3,9 +3,17 @@ package test
 import (
 	"encoding/json"
 	"fmt"
+	"go/build"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/vetcher/godecl"
+	"github.com/vetcher/godecl/printer"
+	"github.com/vetcher/godecl/types"
 )
 
 func TestParser(t *testing.T) {
@@ -19,3 +27,210 @@ func TestParser(t *testing.T) {
 	}
 	fmt.Println(string(bytes))
 }
+
+const importsSource = `package a
+
+import (
+	"strings"
+
+	"github.com/foo/bar/v2"
+	"github.com/mattn/go-sqlite3"
+	"gopkg.in/yaml.v2"
+)
+
+type T struct {
+	B bar.B
+	C *sqlite3.Conn
+	N yaml.Node
+	S strings.Builder
+}
+`
+
+func TestImportNames(t *testing.T) {
+	file := parseSource(t, importsSource)
+	var names []string
+	for _, imp := range file.Imports {
+		names = append(names, imp.Name)
+	}
+	if expected := []string{"strings", "bar", "sqlite3", "yaml"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("import names: %v, expected %v", names, expected)
+	}
+}
+
+func TestImportKinds(t *testing.T) {
+	gopath, err := ioutil.TempDir("", "godecl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(gopath)
+	sources := map[string]string{
+		"dot/dot.go": "package dot\n\ntype Item struct{}\n",
+		"a/a.go": `package a
+
+// #include <stdlib.h>
+import "C"
+
+import (
+	. "dot"
+	_ "net/http/pprof"
+)
+
+type T struct {
+	Item  *Item
+	Size  C.size_t
+	Local Local
+}
+
+type Local int
+`,
+	}
+	for name, src := range sources {
+		filename := filepath.Join(gopath, "src", name)
+		if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filename, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Packages are laid out in GOPATH.
+	defer os.Setenv("GO111MODULE", os.Getenv("GO111MODULE"))
+	os.Setenv("GO111MODULE", "off")
+	ctx := build.Default
+	ctx.GOPATH = gopath
+	ctx.CgoEnabled = true
+	loader := &godecl.Loader{Context: &ctx}
+	pkg, err := loader.Import("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := pkg.Files[0]
+	var kinds []string
+	for _, imp := range file.Imports {
+		kinds = append(kinds, fmt.Sprintf("%s dot=%v blank=%v cgo=%v", imp.Package, imp.Dot, imp.Blank, imp.Cgo))
+	}
+	expected := []string{
+		"a dot=false blank=false cgo=false",
+		"C dot=false blank=false cgo=true",
+		"dot dot=true blank=false cgo=false",
+		"net/http/pprof dot=false blank=true cgo=false",
+	}
+	if !reflect.DeepEqual(kinds, expected) {
+		t.Errorf("imports: %v", kinds)
+	}
+	fields := file.Structures[0].Fields
+	if imp, ok := fields[0].Type.(types.TPointer).Next.(types.TImport); !ok || imp.Import.Package != "dot" {
+		t.Errorf("Item is not resolved in dot-imported package: %#v", fields[0].Type)
+	}
+	if imp, ok := fields[1].Type.(types.TImport); !ok || !imp.Import.Cgo {
+		t.Errorf("C type is not qualified by cgo import: %#v", fields[1].Type)
+	}
+	if _, ok := fields[2].Type.(types.TName); !ok {
+		t.Errorf("Local should not be qualified: %#v", fields[2].Type)
+	}
+	src, err := printer.Source(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"// #include <stdlib.h>\nimport \"C\"", `. "dot"`, `_ "net/http/pprof"`, "Item  *Item"} {
+		if !strings.Contains(string(src), s) {
+			t.Errorf("%q is not printed:\n%s", s, src)
+		}
+	}
+}
+
+const groupsSource = `package a
+
+import "time"
+
+type (
+	// ID docs
+	ID int
+	// S docs
+	S struct{ ID ID }
+	I interface{ Get() S }
+)
+
+// Modes docs
+const (
+	// Off docs
+	Off ID = iota
+	On
+	_
+	Auto = On * 10
+	Half
+)
+
+const (
+	KB = 1 << (10 * (iota + 1))
+	MB
+)
+
+const (
+	A ID = ID(iota)
+	B
+	C = "c"
+	D
+)
+
+const (
+	Second = time.Second * iota
+	Minute
+)
+
+var (
+	// V docs
+	V    int
+	R, W = pipe()
+	X, Y = 1, "y"
+)
+
+func pipe() (int, int) { return 0, 0 }
+`
+
+func TestDeclarationGroups(t *testing.T) {
+	file := parseSource(t, groupsSource)
+	variables := func(vars []types.Variable) []string {
+		var lines []string
+		for _, v := range vars {
+			line := []string{v.Name}
+			if v.Type != nil {
+				line = append(line, printer.TypeString(v.Type))
+			}
+			if v.Value != "" {
+				line = append(line, "= "+v.Value)
+			}
+			if v.ValueIndex > 0 {
+				line = append(line, fmt.Sprintf("#%d", v.ValueIndex))
+			}
+			if text := v.Text(); text != "" {
+				line = append(line, "// "+text)
+			}
+			lines = append(lines, strings.Join(line, " "))
+		}
+		return lines
+	}
+	// Iota is substituted in values, which can't be computed.
+	expected := []string{
+		"Off ID = 0 // Off docs", "On ID = 1 // Modes docs", "_ ID = 2 // Modes docs", "Auto = On * 10 // Modes docs", "Half = 10 // Modes docs",
+		"KB = 1024", "MB = 1048576",
+		"A ID = ID(0)", "B ID = ID(1)", "C STRING = \"c\"", "D STRING = \"c\"",
+		"Second = time.Second * 0", "Minute = time.Second * 1",
+	}
+	if got := variables(file.Constants); !reflect.DeepEqual(got, expected) {
+		t.Errorf("constants:\n%q\nexpected:\n%q", got, expected)
+	}
+	expected = []string{"V int // V docs", "R = pipe()", "W = pipe() #1", "X INT = 1", "Y STRING = \"y\""}
+	if got := variables(file.Vars); !reflect.DeepEqual(got, expected) {
+		t.Errorf("variables:\n%q\nexpected:\n%q", got, expected)
+	}
+	if len(file.Types) != 1 || file.Types[0].Name != "ID" || file.Types[0].Text() != "ID docs" {
+		t.Errorf("types of group: %#v", file.Types)
+	}
+	if len(file.Structures) != 1 || file.Structures[0].Name != "S" || file.Structures[0].Text() != "S docs" {
+		t.Errorf("structures of group: %#v", file.Structures)
+	}
+	if len(file.Interfaces) != 1 || file.Interfaces[0].Name != "I" {
+		t.Errorf("interfaces of group: %#v", file.Interfaces)
+	}
+}
@@ -0,0 +1,338 @@
+package test
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/vetcher/godecl/printer"
+	"github.com/vetcher/godecl/types"
+)
+
+const typesSource = `package a
+
+type MyErr struct{}
+
+func (e *MyErr) Error() string { return "" }
+
+type Bytes []byte
+
+type Reader interface {
+	Read(p []uint8) (int, error)
+}
+
+func (b Bytes) Read(p []byte) (n int, err error) { return }
+`
+
+func TestAssignableTo(t *testing.T) {
+	file := parseSource(t, typesSource)
+	var (
+		myErr    = types.TName{TypeName: "MyErr"}
+		myErrPtr = types.TPointer{NumberOfPointers: 1, Next: myErr}
+		errType  = types.TName{TypeName: "error"}
+		bytes    = types.TName{TypeName: "Bytes"}
+		reader   = types.TName{TypeName: "Reader"}
+		slice    = types.TArray{IsSlice: true, Next: types.TName{TypeName: "uint8"}}
+	)
+	cases := []struct {
+		v, t       types.Type
+		assignable bool
+	}{
+		{myErrPtr, errType, true},
+		{myErr, errType, false},
+		{bytes, reader, true},
+		{types.TPointer{NumberOfPointers: 1, Next: bytes}, reader, true},
+		{bytes, slice, true},
+		{slice, bytes, true},
+		{bytes, types.TName{TypeName: "string"}, false},
+		{types.TChan{Direction: types.ChanDirAny, Next: errType}, types.TChan{Direction: types.ChanDirSend, Next: errType}, true},
+		{reader, reader, true},
+		{types.TPointer{NumberOfPointers: 1, Next: reader}, reader, false},
+		{types.TName{TypeName: "nil"}, types.TPointer{Next: slice}, true},
+		{types.TName{TypeName: "nil"}, types.TPointer{Next: types.TArray{ArrayLen: 2, Next: errType}}, false},
+	}
+	for _, c := range cases {
+		if got := types.AssignableTo(c.v, c.t, file); got != c.assignable {
+			t.Errorf("AssignableTo(%s, %s) = %v, expected %v", c.v, c.t, got, c.assignable)
+		}
+	}
+}
+
+func TestFindImplementations(t *testing.T) {
+	file := parseSource(t, typesSource)
+	pkg := &types.Package{Path: "a", Files: []*types.File{file}}
+	iface := &types.Interface{Methods: []*types.Function{
+		{Base: types.Base{Name: "Error"}, Results: []types.Variable{{Type: types.TName{TypeName: "string"}}}},
+		{
+			Base:    types.Base{Name: "Read"},
+			Args:    []types.Variable{{Type: types.TArray{IsSlice: true, Next: types.TName{TypeName: "byte"}}}},
+			Results: []types.Variable{{Type: types.TName{TypeName: "int"}}, {Type: types.TName{TypeName: "error"}}},
+		},
+	}}
+	impls := types.FindImplementations(iface, "b", true, nil, pkg)
+	if len(impls) != 2 {
+		t.Fatalf("expected 2 near misses, got %d", len(impls))
+	}
+	for _, impl := range impls {
+		if impl.Implements() || len(impl.Missing) != 1 {
+			t.Errorf("%v should miss one method", impl.Decl)
+		}
+	}
+}
+
+const methodSetSource = `package a
+
+type Inner struct{}
+
+func (Inner) Get() int   { return 0 }
+func (*Inner) Set(int)   {}
+func (Inner) Close() error { return nil }
+
+type Other struct{}
+
+func (Other) Close() error { return nil }
+
+type Closer interface {
+	Close() error
+}
+
+type ReadCloser interface {
+	Closer
+	Read() int
+}
+
+type Outer struct {
+	*Inner
+	Other
+}
+
+func (Outer) Get() int { return 1 }
+
+type Wrapper struct {
+	Inner
+	ReadCloser
+}
+`
+
+func TestMethodSet(t *testing.T) {
+	file := parseSource(t, methodSetSource)
+	names := func(sels []types.Selection) (res []string) {
+		for _, sel := range sels {
+			res = append(res, strings.Join(append(sel.Path, sel.Method.Name), "."))
+		}
+		return res
+	}
+	decl, _ := file.Resolve(types.TName{TypeName: "Outer"})
+	ms := decl.(*types.Struct).MethodSet(file)
+	if got := names(ms.Value); !reflect.DeepEqual(got, []string{"Get", "Inner.Set"}) {
+		t.Errorf("Outer value method set: %v", got)
+	}
+	if !reflect.DeepEqual(ms.Ambiguous, []string{"Close"}) {
+		t.Errorf("Outer ambiguous selectors: %v", ms.Ambiguous)
+	}
+	decl, _ = file.Resolve(types.TName{TypeName: "Wrapper"})
+	ms = decl.(*types.Struct).MethodSet(file)
+	if got := names(ms.Value); !reflect.DeepEqual(got, []string{"Inner.Get", "ReadCloser.Read"}) {
+		t.Errorf("Wrapper value method set: %v", got)
+	}
+	if got := names(ms.Pointer); !reflect.DeepEqual(got, []string{"Inner.Get", "ReadCloser.Read", "Inner.Set"}) {
+		t.Errorf("Wrapper pointer method set: %v", got)
+	}
+	if !reflect.DeepEqual(ms.Ambiguous, []string{"Close"}) {
+		t.Errorf("Wrapper ambiguous selectors: %v", ms.Ambiguous)
+	}
+}
+
+// Packages by their import paths.
+type packages map[string]*types.Package
+
+func (p packages) Import(path string) (*types.Package, error) {
+	if pkg, ok := p[path]; ok {
+		return pkg, nil
+	}
+	return nil, fmt.Errorf("package %s is not found", path)
+}
+
+const otherSource = `package other
+
+type Item struct{}
+
+type Getter interface {
+	Get(id int) (*Item, error)
+}
+
+type Base struct{}
+
+func (Base) Item() Item { return Item{} }
+`
+
+const storeSource = `package store
+
+import "example.com/other"
+
+type Store interface {
+	other.Getter
+	Put(item *other.Item) error
+}
+
+type DB struct {
+	other.Base
+}
+`
+
+func TestSelectionPackages(t *testing.T) {
+	imp := packages{
+		"example.com/other": {Path: "example.com/other", Files: []*types.File{parseSource(t, otherSource)}},
+	}
+	file := parseSource(t, storeSource)
+	pkg := &types.Package{Path: "example.com/store", Files: []*types.File{file}}
+	selections := func(sels []types.Selection) (res []string) {
+		for _, sel := range sels {
+			res = append(res, strings.Join(append(sel.Path, sel.Method.Name), ".")+" "+sel.Package)
+		}
+		return res
+	}
+	sels := file.Interfaces[0].Selections(pkg.Scope(imp))
+	if got, expected := selections(sels), []string{"Put ", "Getter.Get example.com/other"}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("interface selections %q, expected %q", got, expected)
+	}
+	// Types of promoted method are moved to package of interface.
+	get := types.RewriteFunction(sels[1].Method, types.Requalifier(sels[1].Package, pkg.Path))
+	if s := get.String(); s != "func Get(id int) (*other.Item, error)" {
+		t.Errorf("requalified method %s", s)
+	}
+	ms := file.Structures[0].MethodSet(pkg.Scope(imp))
+	if got, expected := selections(ms.Value), []string{"Base.Item example.com/other"}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("method set %q, expected %q", got, expected)
+	}
+}
+
+func TestExportedFile(t *testing.T) {
+	file := parseSource(t, `package a
+
+const (
+	A = 1
+	b = 2
+)
+
+var r, W = pipe()
+
+var x, y = pipe()
+
+var Z int
+
+func pipe() (int, int) { return 0, 0 }
+`)
+	src, err := printer.Source(types.ExportedFile(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "package a\n\nconst A = 1\n\nvar r, W = pipe()\n\nvar Z int\n"
+	if string(src) != expected {
+		t.Errorf("exported file:\n%s\nexpected:\n%s", src, expected)
+	}
+}
+
+func TestIndex(t *testing.T) {
+	file := parseSource(t, methodSetSource)
+	pkg := &types.Package{Path: "github.com/acme/a", Files: []*types.File{file}}
+	index := types.NewIndex(pkg)
+	for _, id := range []string{
+		"github.com/acme/a.Inner",
+		"github.com/acme/a.Inner.Set",
+		"github.com/acme/a.Outer.Inner",
+		"github.com/acme/a.ReadCloser.Read",
+	} {
+		node := index.Lookup(id)
+		if node == nil {
+			t.Errorf("%s is not indexed", id)
+			continue
+		}
+		if got := index.QualifiedName(node); got != id {
+			t.Errorf("qualified name of %s is %s", id, got)
+		}
+		if index.Package(node) != pkg {
+			t.Errorf("%s is not linked with package", id)
+		}
+	}
+}
+
+func TestParseTypeString(t *testing.T) {
+	imports := []types.Import{
+		{Package: "github.com/acme/models"},
+		{Base: types.Base{Name: "ctx"}, Package: "context"},
+	}
+	for _, expr := range []string{
+		"map[string][]*models.User",
+		"chan<- error",
+		"chan (<-chan int)",
+		"[...]ctx.Context",
+		"[4]**int",
+		"...string",
+		"interface{}",
+		"interface{ error; Get(ctx ctx.Context, ids ...int) (map[int]models.User, error) }",
+	} {
+		typ, err := types.ParseTypeString(expr, imports)
+		if err != nil {
+			t.Errorf("%s: %v", expr, err)
+			continue
+		}
+		if got := typ.String(); got != expr {
+			t.Errorf("%s: parsed as %s", expr, got)
+		}
+	}
+	if _, err := types.ParseTypeString("unknown.Type", imports); err == nil {
+		t.Error("unknown package should not be resolved")
+	}
+
+	// Types of declarations are the same, as parsed from strings.
+	file := parseSource(t, "package a\nvar A [0x10]int\nvar B [1_000]*int\nvar C [N]int\nvar D interface{ Get(int) error }")
+	for i, expr := range []string{"[0x10]int", "[1_000]*int", "[N]int", "interface{ Get(int) error }"} {
+		typ, err := types.ParseTypeString(expr, nil)
+		if err != nil {
+			t.Errorf("%s: %v", expr, err)
+			continue
+		}
+		if !reflect.DeepEqual(typ, file.Vars[i].Type) {
+			t.Errorf("%s: parsed as %#v, parser of files returns %#v", expr, typ, file.Vars[i].Type)
+		}
+	}
+	if typ, _ := types.ParseTypeString("[0x10]int", nil); typ.(types.TArray).ArrayLen != 16 {
+		t.Errorf("length of [0x10]int is %d", typ.(types.TArray).ArrayLen)
+	}
+}
+
+func TestImportSet(t *testing.T) {
+	imports := []types.Import{
+		{Package: "errors"},
+		{Package: "github.com/pkg/errors"},
+		{Package: "github.com/acme/svc"},
+		{Package: "github.com/acme/api/errors"},
+	}
+	var ts []types.Type
+	for _, expr := range []string{"map[errors.Code]*svc.User", "chan errors.Frame"} {
+		typ, err := types.ParseTypeString(expr, imports[1:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		ts = append(ts, typ)
+	}
+	ts = append(ts, types.TImport{Import: &imports[0], Next: types.TName{TypeName: "Error"}})
+	set := types.NewImportSet("github.com/acme/svc")
+	set.Add(ts...)
+	var got []string
+	for _, typ := range ts {
+		got = append(got, set.Qualify(typ).String())
+	}
+	expected := []string{"map[pkgerrors.Code]*User", "chan pkgerrors.Frame", "errors.Error"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("qualified types: %v", got)
+	}
+	if alias := set.AddPath("github.com/acme/api/errors"); alias != "apierrors" {
+		t.Errorf("alias of late package: %s", alias)
+	}
+	if n := len(set.Imports()); n != 3 {
+		t.Errorf("expected 3 imports, got %d", n)
+	}
+}
@@ -0,0 +1,127 @@
+package test
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/vetcher/godecl/types"
+)
+
+const walkSource = `package a
+
+type S struct {
+	M map[string]*int
+}
+
+func F(s []S) error { return nil }
+`
+
+// Returns type and name of node, e.g. `Struct S` or `TName int`.
+func nodeString(node types.Node) string {
+	switch n := node.(type) {
+	case nil:
+		return "nil"
+	case types.TName:
+		return "TName " + n.TypeName
+	case *types.Struct:
+		return "Struct " + n.Name
+	case *types.StructField:
+		return "StructField " + n.Name
+	case *types.Function:
+		return "Function " + n.Name
+	case *types.Variable:
+		return "Variable " + n.Name
+	}
+	return strings.TrimPrefix(strings.TrimPrefix(fmt.Sprintf("%T", node), "*"), "types.")
+}
+
+type recorder []string
+
+func (r *recorder) Visit(node types.Node) types.Visitor {
+	*r = append(*r, nodeString(node))
+	return r
+}
+
+func TestWalk(t *testing.T) {
+	file := parseSource(t, walkSource)
+	var r recorder
+	types.Walk(&r, &file.Structures[0])
+	expected := []string{
+		"Struct S", "StructField M", "TMap", "TName string", "nil", "TPointer", "TName int", "nil", "nil", "nil", "nil", "nil",
+	}
+	if !reflect.DeepEqual([]string(r), expected) {
+		t.Errorf("visited %q, expected %q", r, expected)
+	}
+}
+
+func TestInspect(t *testing.T) {
+	file := parseSource(t, walkSource)
+	var visited []string
+	types.Inspect(file, func(node types.Node) bool {
+		if node != nil {
+			visited = append(visited, nodeString(node))
+		}
+		// Fields of structures are skipped.
+		_, isStruct := node.(*types.Struct)
+		return !isStruct
+	})
+	expected := []string{
+		"File", "Struct S", "Function F", "Variable s", "TArray", "TName S", "Variable ", "TName error",
+	}
+	if !reflect.DeepEqual(visited, expected) {
+		t.Errorf("visited %q, expected %q", visited, expected)
+	}
+}
+
+func TestApply(t *testing.T) {
+	file := parseSource(t, walkSource)
+	var pre, post []string
+	types.Apply(&file.Functions[0], func(c *types.Cursor) bool {
+		pre = append(pre, nodeString(c.Node()))
+		return true
+	}, func(c *types.Cursor) {
+		post = append(post, nodeString(c.Node()))
+	})
+	if expected := []string{"Function F", "Variable s", "TArray", "TName S", "Variable ", "TName error"}; !reflect.DeepEqual(pre, expected) {
+		t.Errorf("pre visited %q, expected %q", pre, expected)
+	}
+	if expected := []string{"TName S", "TArray", "Variable s", "TName error", "Variable ", "Function F"}; !reflect.DeepEqual(post, expected) {
+		t.Errorf("post visited %q, expected %q", post, expected)
+	}
+
+	// Replaces `int` by `*int64`, pointers are collapsed and map is rebuilt.
+	field := &file.Structures[0].Fields[0]
+	old := field.Type
+	types.Apply(file, nil, func(c *types.Cursor) {
+		if name, ok := c.Node().(types.TName); ok && name.TypeName == "int" {
+			if _, ok := c.Parent().(types.TPointer); !ok {
+				t.Errorf("parent of int is %T", c.Parent())
+			}
+			c.Replace(types.TPointer{NumberOfPointers: 1, Next: types.TName{TypeName: "int64"}})
+		}
+	})
+	expected := types.TMap{
+		Key:   types.TName{TypeName: "string"},
+		Value: types.TPointer{NumberOfPointers: 2, Next: types.TName{TypeName: "int64"}},
+	}
+	if !reflect.DeepEqual(field.Type, expected) {
+		t.Errorf("replaced type %#v, expected %#v", field.Type, expected)
+	}
+	if !reflect.DeepEqual(old, types.TMap{
+		Key:   types.TName{TypeName: "string"},
+		Value: types.TPointer{NumberOfPointers: 1, Next: types.TName{TypeName: "int"}},
+	}) {
+		t.Errorf("original type value is changed: %#v", old)
+	}
+
+	// Root type is replaced too.
+	root := types.Apply(types.TName{TypeName: "T"}, func(c *types.Cursor) bool {
+		c.Replace(types.TName{TypeName: "U"})
+		return false
+	}, nil)
+	if !reflect.DeepEqual(root, types.TName{TypeName: "U"}) {
+		t.Errorf("replaced root %#v", root)
+	}
+}
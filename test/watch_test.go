@@ -0,0 +1,99 @@
+package test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/vetcher/godecl"
+	"github.com/vetcher/godecl/types"
+)
+
+func TestWatcher(t *testing.T) {
+	dir, err := ioutil.TempDir("", "godecl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "a.go")
+	write := func(src string) {
+		if err := ioutil.WriteFile(filename, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("package a\n\nfunc F() {}\n\nfunc G() {}\n")
+	w, err := godecl.NewWatcher(10*time.Millisecond, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	write("package a\n\nfunc F(x int) {}\n\ntype T struct{}\n")
+	select {
+	case events := <-w.Events:
+		expected := map[string]godecl.EventKind{
+			dir + ".F": godecl.DeclChanged,
+			dir + ".G": godecl.DeclRemoved,
+			dir + ".T": godecl.DeclAdded,
+		}
+		if len(events) != len(expected) {
+			t.Fatalf("unexpected events: %v", events)
+		}
+		for _, ev := range events {
+			if kind, ok := expected[ev.Name]; !ok || kind != ev.Kind {
+				t.Errorf("unexpected event %s %s", ev.Kind, ev.Name)
+			}
+		}
+	case err := <-w.Errors:
+		t.Fatal(err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no events")
+	}
+}
+
+func TestWatcherPackageNames(t *testing.T) {
+	dir, err := ioutil.TempDir("", "godecl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	write := func(name, src string) {
+		filename := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filename, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("b/b.go", "package bee\n\ntype T int\n")
+	write("a.go", "package a\n\nimport \"./b\"\n\nvar V bee.T\n")
+	w, err := godecl.NewWatcher(10*time.Millisecond, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Closing twice is allowed.
+	defer w.Close()
+	defer w.Close()
+	// Package clause is renamed, so files of watched package are changed too.
+	write("b/b.go", "package bea\n\ntype T int\n")
+	time.Sleep(50 * time.Millisecond)
+	write("a.go", "package a\n\nimport \"./b\"\n\nvar V bea.T\n\nvar W int\n")
+	select {
+	case events := <-w.Events:
+		for _, ev := range events {
+			v, ok := ev.Decl.(*types.Variable)
+			if !ok || v.Name != "V" {
+				continue
+			}
+			if imp := types.TypeImport(v.Type); imp == nil || imp.Name != "bea" {
+				t.Errorf("import of renamed package: %#v", imp)
+			}
+		}
+	case err := <-w.Errors:
+		t.Fatal(err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no events")
+	}
+}
@@ -1,5 +1,7 @@
 package types
 
+import "strings"
+
 // Base type for all (almost) entities.
 // It contains name of entity and docs.
 // Docs is a comments in golang syntax above entity declaration.
@@ -8,3 +10,65 @@ type Base struct {
 	Name string   `json:"name,omitempty"`
 	Docs []string `json:"docs,omitempty"`
 }
+
+// Annotation is a line of docs in form `// @key value`.
+type Annotation struct {
+	Key   string `json:"key"`
+	Value string `json:"value,omitempty"`
+}
+
+// Returns annotations from docs in order of their appearance.
+func (b Base) Annotations() []Annotation {
+	var annotations []Annotation
+	for _, line := range docLines(b.Docs) {
+		if !isAnnotation(line) {
+			continue
+		}
+		a := Annotation{Key: line[1:]}
+		if i := strings.IndexAny(a.Key, " \t"); i >= 0 {
+			a.Key, a.Value = a.Key[:i], strings.TrimSpace(a.Key[i:])
+		}
+		annotations = append(annotations, a)
+	}
+	return annotations
+}
+
+// Returns text of docs without comment markers and annotations, lines are joined by newlines.
+func (b Base) Text() string {
+	var lines []string
+	for _, line := range docLines(b.Docs) {
+		if !isAnnotation(line) {
+			lines = append(lines, line)
+		}
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// Returns lines of docs without comment markers.
+func docLines(docs []string) []string {
+	var lines []string
+	for _, doc := range docs {
+		for _, line := range strings.Split(doc, "\n") {
+			line = strings.TrimSpace(line)
+			line = strings.TrimPrefix(line, "//")
+			line = strings.TrimPrefix(line, "/*")
+			line = strings.TrimSuffix(line, "*/")
+			lines = append(lines, strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "*")))
+		}
+	}
+	return lines
+}
+
+func isAnnotation(line string) bool {
+	return strings.HasPrefix(line, "@") && len(line) > 1
+}
+
+// Returns value of the first annotation with key and whether it is found.
+func (b Base) Annotation(key string) (string, bool) {
+	for _, a := range b.Annotations() {
+		if a.Key == key {
+			return a.Value, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,100 @@
+package types
+
+import "go/ast"
+
+// Returns copy of file, which contains only exported declarations: constants, variables, types,
+// functions, methods of exported types, exported fields of structures and exported methods of interfaces.
+// Imports are kept as is.
+func ExportedFile(f *File) *File {
+	nf := *f
+	nf.Constants = exportedVariables(f.Constants)
+	nf.Vars = exportedVariables(f.Vars)
+	nf.Functions = nil
+	for _, fn := range f.Functions {
+		if ast.IsExported(fn.Name) {
+			nf.Functions = append(nf.Functions, fn)
+		}
+	}
+	nf.Interfaces = nil
+	for _, i := range f.Interfaces {
+		if !ast.IsExported(i.Name) {
+			continue
+		}
+		var methods []*Function
+		for _, m := range i.Methods {
+			if ast.IsExported(m.Name) {
+				methods = append(methods, m)
+			}
+		}
+		i.Methods = methods
+		nf.Interfaces = append(nf.Interfaces, i)
+	}
+	nf.Methods = nil
+	var kept []*Method
+	for i, m := range f.Methods {
+		if recv := TypeName(m.Receiver.Type); recv != nil && ast.IsExported(*recv) && ast.IsExported(m.Name) {
+			nf.Methods = append(nf.Methods, m)
+			kept = append(kept, &f.Methods[i])
+		}
+	}
+	copies := make(map[*Method]*Method)
+	for i, m := range kept {
+		copies[m] = &nf.Methods[i]
+	}
+	nf.Structures = nil
+	for _, s := range f.Structures {
+		if !ast.IsExported(s.Name) {
+			continue
+		}
+		var fields []StructField
+		for _, field := range s.Fields {
+			if ast.IsExported(fieldName(field)) {
+				fields = append(fields, field)
+			}
+		}
+		s.Fields = fields
+		s.Methods = exportedMethods(s.Methods, copies)
+		nf.Structures = append(nf.Structures, s)
+	}
+	nf.Types = nil
+	for _, t := range f.Types {
+		if ast.IsExported(t.Name) {
+			t.Methods = exportedMethods(t.Methods, copies)
+			nf.Types = append(nf.Types, t)
+		}
+	}
+	return &nf
+}
+
+// Variables, assigned by results of one call, e.g. `var a, B = f()`, are kept together,
+// when some of them are exported.
+func exportedVariables(vars []Variable) []Variable {
+	var exported []Variable
+	for i := 0; i < len(vars); {
+		j := i + 1
+		for j < len(vars) && vars[j].ValueIndex == vars[j-1].ValueIndex+1 && vars[j].Value == vars[j-1].Value {
+			j++
+		}
+		for _, v := range vars[i:j] {
+			if ast.IsExported(v.Name) {
+				exported = append(exported, vars[i:j]...)
+				break
+			}
+		}
+		i = j
+	}
+	return exported
+}
+
+// Methods of other files are kept, when they are exported.
+func exportedMethods(methods []*Method, copies map[*Method]*Method) []*Method {
+	var exported []*Method
+	for _, m := range methods {
+		if c, ok := copies[m]; ok {
+			exported = append(exported, c)
+		} else if ast.IsExported(m.Name) {
+			exported = append(exported, m)
+		}
+	}
+	return exported
+}
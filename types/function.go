@@ -24,7 +24,14 @@ func (f Function) funcStr() string {
 	for _, res := range f.Results {
 		results = append(results, res.String())
 	}
-	return fmt.Sprintf("%s(%s) (%s)", f.Name, strings.Join(args, ", "), strings.Join(results, ", "))
+	switch {
+	case len(results) == 0:
+		return fmt.Sprintf("%s(%s)", f.Name, strings.Join(args, ", "))
+	case len(results) == 1 && f.Results[0].Name == "":
+		return fmt.Sprintf("%s(%s) %s", f.Name, strings.Join(args, ", "), results[0])
+	default:
+		return fmt.Sprintf("%s(%s) (%s)", f.Name, strings.Join(args, ", "), strings.Join(results, ", "))
+	}
 }
 
 func (f Function) String() string {
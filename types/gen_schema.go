@@ -0,0 +1,22 @@
+//go:build ignore
+// +build ignore
+
+// Writes JSON Schema of the model to schema.json.
+package main
+
+import (
+	"io/ioutil"
+	"log"
+
+	"github.com/vetcher/godecl/types"
+)
+
+func main() {
+	schema, err := types.JSONSchema()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := ioutil.WriteFile("schema.json", append(schema, '\n'), 0644); err != nil {
+		log.Fatal(err)
+	}
+}
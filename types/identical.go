@@ -0,0 +1,317 @@
+package types
+
+// Resolver finds declarations of named types.
+type Resolver interface {
+	// Returns declaration of named type t (*Struct, *Interface or *FileType) and Resolver
+	// of the scope, where declaration was found.
+	// Returns nil declaration, when type is not declared or can't be found.
+	Resolve(t Type) (decl interface{}, scope Resolver)
+}
+
+// Resolves types, declared in file.
+func (f *File) Resolve(t Type) (interface{}, Resolver) {
+	name, ok := t.(TName)
+	if !ok || IsBuiltinTypeString(name.TypeName) {
+		return nil, nil
+	}
+	for i := range f.Structures {
+		if f.Structures[i].Name == name.TypeName {
+			return &f.Structures[i], f
+		}
+	}
+	for i := range f.Interfaces {
+		if f.Interfaces[i].Name == name.TypeName {
+			return &f.Interfaces[i], f
+		}
+	}
+	for i := range f.Types {
+		if f.Types[i].Name == name.TypeName {
+			return &f.Types[i], f
+		}
+	}
+	return nil, nil
+}
+
+// Builtin `error` interface.
+var errorInterface = &Interface{
+	Base: Base{Name: "error"},
+	Methods: []*Function{
+		{Base: Base{Name: "Error"}, Results: []Variable{{Type: TName{TypeName: "string"}}}},
+	},
+}
+
+// Aliases of builtin types.
+var builtinAliases = map[string]string{
+	"byte": "uint8",
+	"rune": "int32",
+}
+
+// Reports whether a and b are identical types.
+// Types are compared by their structure, named types from other packages are compared by import paths.
+func Identical(a, b Type) bool {
+	a, b = collapsePointers(a), collapsePointers(b)
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	if a.TypeOf() != b.TypeOf() {
+		return false
+	}
+	switch x := a.(type) {
+	case TName:
+		return builtinName(x.TypeName) == builtinName(b.(TName).TypeName)
+	case TPointer:
+		y := b.(TPointer)
+		return x.NumberOfPointers == y.NumberOfPointers && Identical(x.Next, y.Next)
+	case TArray:
+		y := b.(TArray)
+		return x.IsSlice == y.IsSlice && x.IsEllipsis == y.IsEllipsis && x.ArrayLen == y.ArrayLen && Identical(x.Next, y.Next)
+	case TMap:
+		y := b.(TMap)
+		return Identical(x.Key, y.Key) && Identical(x.Value, y.Value)
+	case TInterface:
+		return identicalInterfaces(x.Interface, b.(TInterface).Interface)
+	case TImport:
+		y := b.(TImport)
+		return samePackage(x.Import, y.Import) && Identical(x.Next, y.Next)
+	case TEllipsis:
+		return Identical(x.Next, b.(TEllipsis).Next)
+	case TChan:
+		y := b.(TChan)
+		return x.Direction == y.Direction && Identical(x.Next, y.Next)
+	}
+	return false
+}
+
+func builtinName(name string) string {
+	if alias, ok := builtinAliases[name]; ok {
+		return alias
+	}
+	return name
+}
+
+// Merges nested pointers, so `*(*T)` becomes `**T`.
+func collapsePointers(t Type) Type {
+	p, ok := t.(TPointer)
+	if !ok {
+		return t
+	}
+	for {
+		next, ok := p.Next.(TPointer)
+		if !ok {
+			break
+		}
+		p = TPointer{NumberOfPointers: p.NumberOfPointers + next.NumberOfPointers, Next: next.Next}
+	}
+	if p.NumberOfPointers == 0 {
+		return p.Next
+	}
+	return p
+}
+
+func samePackage(a, b *Import) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Package == b.Package
+}
+
+// Interfaces are compared by their own methods and embedded interfaces, which are not resolved.
+func identicalInterfaces(a, b *Interface) bool {
+	if a == nil {
+		a = &Interface{}
+	}
+	if b == nil {
+		b = &Interface{}
+	}
+	if len(a.Methods) != len(b.Methods) || len(missingMethods(a.Methods, b.Methods)) != 0 {
+		return false
+	}
+	if len(a.Embedded) != len(b.Embedded) {
+		return false
+	}
+	for _, x := range a.Embedded {
+		found := false
+		for _, y := range b.Embedded {
+			if Identical(x, y) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// Reports whether functions have identical args and results. Names of params are ignored.
+func identicalSignatures(a, b *Function) bool {
+	if len(a.Args) != len(b.Args) || len(a.Results) != len(b.Results) {
+		return false
+	}
+	for i := range a.Args {
+		if !Identical(a.Args[i].Type, b.Args[i].Type) {
+			return false
+		}
+	}
+	for i := range a.Results {
+		if !Identical(a.Results[i].Type, b.Results[i].Type) {
+			return false
+		}
+	}
+	return true
+}
+
+// Returns methods of required, which are absent in methods or have other signature.
+func missingMethods(methods, required []*Function) []*Function {
+	var missing []*Function
+	for _, req := range required {
+		found := false
+		for _, m := range methods {
+			if m.Name == req.Name && identicalSignatures(m, req) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, req)
+		}
+	}
+	return missing
+}
+
+// Returns method set of value of declared type (*Struct, *FileType or *Interface).
+// When pointer is true, method set of pointer to the type is returned.
+func declMethods(decl interface{}, pointer bool, r Resolver) []*Function {
+	var ms MethodSet
+	switch d := decl.(type) {
+	case *Interface:
+		return d.AllMethods(r)
+	case *Struct:
+		ms = d.MethodSet(r)
+	case *FileType:
+		ms = d.MethodSet(r)
+	}
+	sels := ms.Value
+	if pointer {
+		sels = ms.Pointer
+	}
+	var fns []*Function
+	for _, sel := range sels {
+		fns = append(fns, sel.Method)
+	}
+	return fns
+}
+
+func isPointerReceiver(m *Method) bool {
+	_, ok := m.Receiver.Type.(TPointer)
+	return ok
+}
+
+// Returns methods of iface, which are not implemented by declared type decl (*Struct, *FileType or *Interface).
+// When pointer is true, methods with pointer receivers are counted too.
+// Embedded fields and interfaces are found by resolver r, which may be nil.
+func MissingMethods(decl interface{}, pointer bool, iface *Interface, r Resolver) []*Function {
+	return missingMethods(declMethods(decl, pointer, r), iface.AllMethods(r))
+}
+
+// Returns interface, described by type t, or nil, if t is not an interface.
+func interfaceOf(t Type, r Resolver) *Interface {
+	switch tt := t.(type) {
+	case TInterface:
+		if tt.Interface == nil {
+			return &Interface{}
+		}
+		return tt.Interface
+	case TName:
+		if tt.TypeName == "error" {
+			return errorInterface
+		}
+	}
+	if r == nil {
+		return nil
+	}
+	decl, _ := r.Resolve(t)
+	iface, _ := decl.(*Interface)
+	return iface
+}
+
+// Reports whether value of type t implements iface.
+// Declarations of named types are found by resolver r, which may be nil.
+func Implements(t Type, iface *Interface, r Resolver) bool {
+	if i := interfaceOf(t, r); i != nil {
+		return len(missingMethods(i.AllMethods(r), iface.AllMethods(r))) == 0
+	}
+	if len(iface.AllMethods(r)) == 0 {
+		return true
+	}
+	pointer := false
+	if p, ok := collapsePointers(t).(TPointer); ok && p.NumberOfPointers == 1 {
+		pointer, t = true, p.Next
+	}
+	if r == nil {
+		return false
+	}
+	decl, scope := r.Resolve(t)
+	if decl == nil {
+		return false
+	}
+	// Method set of pointer to interface is empty.
+	if _, ok := decl.(*Interface); ok && pointer {
+		return false
+	}
+	return len(missingMethods(declMethods(decl, pointer, scope), iface.AllMethods(r))) == 0
+}
+
+// Reports whether value of type v is assignable to variable of type t.
+// Declarations of named types are found by resolver r, which may be nil.
+func AssignableTo(v, t Type, r Resolver) bool {
+	if Identical(v, t) {
+		return true
+	}
+	if name, ok := v.(TName); ok && name.TypeName == "nil" {
+		switch tt := collapsePointers(t).(type) {
+		case TPointer, TMap, TChan, TInterface:
+			return true
+		case TArray:
+			return tt.IsSlice
+		}
+		return interfaceOf(t, r) != nil
+	}
+	if iface := interfaceOf(t, r); iface != nil {
+		return Implements(v, iface, r)
+	}
+	// Bidirectional channel is assignable to directional one with identical element type.
+	if vc, ok := v.(TChan); ok && vc.Direction == ChanDirAny {
+		if tc, ok := t.(TChan); ok && Identical(vc.Next, tc.Next) {
+			return true
+		}
+	}
+	// Named and unnamed types with identical underlying types are assignable.
+	if isNamed(v) != isNamed(t) && r != nil {
+		if isNamed(v) {
+			return Identical(underlying(v, r), t)
+		}
+		return Identical(v, underlying(t, r))
+	}
+	return false
+}
+
+// Predeclared types are named too.
+func isNamed(t Type) bool {
+	switch t.(type) {
+	case TName, TImport:
+		return true
+	}
+	return false
+}
+
+// Returns underlying type of named type, declared with `type X Y`, or t itself.
+func underlying(t Type, r Resolver) Type {
+	if decl, _ := r.Resolve(t); decl != nil {
+		if ft, ok := decl.(*FileType); ok {
+			return ft.Type
+		}
+	}
+	return t
+}
@@ -0,0 +1,75 @@
+package types
+
+// Implementation is a declared type, which implements an interface or nearly misses it.
+type Implementation struct {
+	Package string      // Import path of package, where type is declared.
+	Decl    interface{} // *Struct or *FileType.
+	// Type implements interface only by pointer, because some of methods have pointer receivers.
+	Pointer bool
+	// Methods of interface, which are not implemented or have other signatures, even when pointer receivers are counted.
+	// It is empty for implementations.
+	Missing []*Function
+}
+
+func (i Implementation) Implements() bool {
+	return len(i.Missing) == 0
+}
+
+// Finds structures and named types of packages, which implement iface, declared in package ifacePath.
+// When nearMisses is true, types, which implement at least one of methods of iface, are returned too.
+// Methods of packages should be linked with declarations across files, as ParsePackage does.
+// Embedded types and interfaces from other packages are loaded by imp, which may be nil.
+func FindImplementations(iface *Interface, ifacePath string, nearMisses bool, imp Importer, pkgs ...*Package) []Implementation {
+	var found []Implementation
+	for _, pkg := range pkgs {
+		scope := pkg.Scope(imp)
+		local := iface
+		if pkg.Path != ifacePath {
+			local = RewriteInterface(iface, Requalifier(ifacePath, pkg.Path))
+		}
+		check := func(decl interface{}) {
+			impl := Implementation{Package: pkg.Path, Decl: decl}
+			if len(MissingMethods(decl, false, local, scope)) == 0 {
+				found = append(found, impl)
+				return
+			}
+			impl.Pointer = true
+			impl.Missing = MissingMethods(decl, true, local, scope)
+			if len(impl.Missing) == 0 || nearMisses && len(impl.Missing) < len(local.AllMethods(scope)) {
+				found = append(found, impl)
+			}
+		}
+		for _, file := range pkg.Files {
+			for i := range file.Structures {
+				check(&file.Structures[i])
+			}
+			for i := range file.Types {
+				check(&file.Types[i])
+			}
+		}
+	}
+	return found
+}
+
+// Returns rewriter, which moves types from package `from` to package `to`:
+// unqualified types of `from` become qualified by import of `from`,
+// and qualified types of `to` become unqualified.
+func Requalifier(from, to string) func(Type) (Type, bool) {
+	imp := &Import{Base: Base{Name: AssumedPackageName(from)}, Package: from}
+	return func(t Type) (Type, bool) {
+		switch tt := t.(type) {
+		case TName:
+			if IsBuiltinTypeString(tt.TypeName) {
+				return nil, false
+			}
+			return TImport{Import: imp, Next: tt}, true
+		case TImport:
+			if tt.Import != nil && tt.Import.Package == to {
+				return tt.Next, true
+			}
+			// Types of other packages should not be qualified again.
+			return tt, true
+		}
+		return nil, false
+	}
+}
@@ -1,10 +1,19 @@
 package types
 
-import "fmt"
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"unicode"
+)
 
 type Import struct {
 	Base
 	Package string `json:"package,omitempty"`
+	Dot     bool   `json:"dot,omitempty"`   // `import . "pkg"`, identifiers of package are used unqualified.
+	Blank   bool   `json:"blank,omitempty"` // `import _ "pkg"`, package is imported for side effects.
+	Cgo     bool   `json:"cgo,omitempty"`   // `import "C"`, types of package are C types.
 }
 
 func (i Import) String() string {
@@ -14,3 +23,32 @@ func (i Import) String() string {
 func (i Import) GoString() string {
 	return i.String()
 }
+
+// Returns name of package, assumed by its import path, as Go tools do, when package sources are not available:
+// major version suffix is skipped, so `github.com/foo/bar/v2` is `bar`, `go-` prefix is trimmed
+// and name is cut at first character, which is not allowed in identifiers, so `gopkg.in/yaml.v2` is `yaml`.
+func AssumedPackageName(pkgPath string) string {
+	base := path.Base(trimMajorVersion(pkgPath))
+	base = strings.TrimPrefix(base, "go-")
+	if i := strings.IndexFunc(base, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	}); i >= 0 {
+		base = base[:i]
+	}
+	return base
+}
+
+// Trims major version element, e.g. `/v2`, from the end of import path.
+func trimMajorVersion(pkgPath string) string {
+	base := path.Base(pkgPath)
+	if len(base) < 2 || base[0] != 'v' {
+		return pkgPath
+	}
+	if _, err := strconv.Atoi(base[1:]); err != nil {
+		return pkgPath
+	}
+	if dir := path.Dir(pkgPath); dir != "." {
+		return dir
+	}
+	return pkgPath
+}
@@ -0,0 +1,161 @@
+package types
+
+import (
+	"go/token"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ImportSet plans imports of generated file: it collects packages of used types and assigns them
+// unique aliases. Aliases are assigned deterministically, when they are requested the first time:
+// packages with shorter paths keep their names, others are prefixed by parent element of path or numbered.
+// To get aliases, which depend only on the set of packages, add all types before qualifying them.
+type ImportSet struct {
+	path     string
+	paths    map[string]bool
+	aliases  map[string]string
+	reserved map[string]bool
+}
+
+// Returns import set for file of package with import path pkgPath.
+// Types of this package are never qualified.
+func NewImportSet(pkgPath string) *ImportSet {
+	return &ImportSet{
+		path:     pkgPath,
+		paths:    make(map[string]bool),
+		aliases:  make(map[string]string),
+		reserved: make(map[string]bool),
+	}
+}
+
+// Forbids names to be used as aliases, e.g. names of declarations or variables of generated file.
+func (s *ImportSet) Reserve(names ...string) {
+	for _, name := range names {
+		s.reserved[name] = true
+	}
+}
+
+// Collects packages of all qualified types, which are used in ts.
+func (s *ImportSet) Add(ts ...Type) {
+	for _, t := range ts {
+		Inspect(t, func(n Node) bool {
+			if tt, ok := n.(TImport); ok && tt.Import != nil {
+				s.addPath(tt.Import.Package)
+			}
+			return true
+		})
+	}
+}
+
+// Adds package by its import path and returns its alias.
+func (s *ImportSet) AddPath(pkgPath string) string {
+	s.addPath(pkgPath)
+	s.assign()
+	return s.aliases[pkgPath]
+}
+
+func (s *ImportSet) addPath(pkgPath string) {
+	if pkgPath != "" && pkgPath != s.path {
+		s.paths[pkgPath] = true
+	}
+}
+
+// Returns copy of t, where qualified types refer to imports of the set.
+// Packages of t, which were not added before, are added.
+func (s *ImportSet) Qualify(t Type) Type {
+	return Rewrite(t, s.Rewriter(""))
+}
+
+// Returns rewriter, which can be passed to RewriteFunction, RewriteInterface, etc.
+// When from is not empty, unqualified types, except builtin ones, are treated as declared in package from.
+func (s *ImportSet) Rewriter(from string) func(Type) (Type, bool) {
+	return func(t Type) (Type, bool) {
+		switch tt := t.(type) {
+		case TName:
+			if from == "" || from == s.path || IsBuiltinTypeString(tt.TypeName) {
+				return nil, false
+			}
+			return s.qualified(from, tt), true
+		case TImport:
+			if tt.Import == nil {
+				return nil, false
+			}
+			if tt.Import.Package == s.path {
+				return tt.Next, true
+			}
+			// Types of qualified type are not qualified again.
+			return s.qualified(tt.Import.Package, tt.Next), true
+		}
+		return nil, false
+	}
+}
+
+func (s *ImportSet) qualified(pkgPath string, next Type) Type {
+	return TImport{Import: &Import{Base: Base{Name: s.AddPath(pkgPath)}, Package: pkgPath}, Next: next}
+}
+
+// Returns imports of the set, sorted by paths. Name of each import is its alias.
+func (s *ImportSet) Imports() []Import {
+	s.assign()
+	imports := make([]Import, 0, len(s.paths))
+	for p := range s.paths {
+		imports = append(imports, Import{Base: Base{Name: s.aliases[p]}, Package: p})
+	}
+	sort.Slice(imports, func(i, j int) bool { return imports[i].Package < imports[j].Package })
+	return imports
+}
+
+// Assigns aliases to packages, which have no alias yet.
+func (s *ImportSet) assign() {
+	var paths []string
+	for p := range s.paths {
+		if _, ok := s.aliases[p]; !ok {
+			paths = append(paths, p)
+		}
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		ni, nj := strings.Count(paths[i], "/"), strings.Count(paths[j], "/")
+		if ni != nj {
+			return ni < nj
+		}
+		return paths[i] < paths[j]
+	})
+	used := make(map[string]bool)
+	for _, alias := range s.aliases {
+		used[alias] = true
+	}
+	free := func(name string) bool {
+		return !used[name] && !s.reserved[name] && !token.IsKeyword(name)
+	}
+	for _, p := range paths {
+		name := identifier(AssumedPackageName(p))
+		alias := name
+		if !free(alias) {
+			if parent := path.Base(path.Dir(trimMajorVersion(p))); parent != "." && parent != "/" {
+				alias = identifier(parent) + name
+			}
+		}
+		for i := 2; !free(alias); i++ {
+			alias = name + strconv.Itoa(i)
+		}
+		used[alias] = true
+		s.aliases[p] = alias
+	}
+}
+
+// Replaces characters, which are not allowed in identifiers, by underscores.
+func identifier(name string) string {
+	id := []rune(name)
+	for i, r := range id {
+		if !unicode.IsLetter(r) && r != '_' && (i == 0 || !unicode.IsDigit(r)) {
+			id[i] = '_'
+		}
+	}
+	if len(id) == 0 {
+		return "pkg"
+	}
+	return string(id)
+}
@@ -0,0 +1,123 @@
+package types
+
+import (
+	"sort"
+	"strings"
+)
+
+// Index maps declarations of packages to their qualified names and back.
+//
+// Qualified name is a package path, followed by names of entities, separated by dots:
+// `github.com/acme/svc.UserService` for top-level declarations,
+// `github.com/acme/svc.UserService.Get` for methods, fields of structures and methods of interfaces.
+// Embedded fields are named by their type names. When package path is empty, package name is used.
+// Blank identifiers and `init` functions are not indexed.
+type Index struct {
+	ids      map[Node]string
+	nodes    map[string]Node
+	packages map[Node]*Package
+}
+
+// Builds index of declarations of packages.
+func NewIndex(pkgs ...*Package) *Index {
+	x := &Index{
+		ids:      make(map[Node]string),
+		nodes:    make(map[string]Node),
+		packages: make(map[Node]*Package),
+	}
+	for _, pkg := range pkgs {
+		prefix := pkg.Path
+		if prefix == "" {
+			prefix = pkg.Name
+		}
+		for _, f := range pkg.Files {
+			x.addFile(pkg, prefix, f)
+		}
+	}
+	return x
+}
+
+// Returns qualified name, built from package path and names of entities.
+func QualifiedName(pkgPath string, names ...string) string {
+	return strings.Join(append([]string{pkgPath}, names...), ".")
+}
+
+func (x *Index) addFile(pkg *Package, prefix string, f *File) {
+	for i := range f.Constants {
+		x.add(pkg, &f.Constants[i], prefix, f.Constants[i].Name)
+	}
+	for i := range f.Vars {
+		x.add(pkg, &f.Vars[i], prefix, f.Vars[i].Name)
+	}
+	for i := range f.Types {
+		x.add(pkg, &f.Types[i], prefix, f.Types[i].Name)
+	}
+	for i := range f.Functions {
+		x.add(pkg, &f.Functions[i], prefix, f.Functions[i].Name)
+	}
+	for i := range f.Interfaces {
+		iface := &f.Interfaces[i]
+		x.add(pkg, iface, prefix, iface.Name)
+		for _, m := range iface.Methods {
+			x.add(pkg, m, prefix, iface.Name, m.Name)
+		}
+	}
+	for i := range f.Structures {
+		s := &f.Structures[i]
+		x.add(pkg, s, prefix, s.Name)
+		for j := range s.Fields {
+			x.add(pkg, &s.Fields[j], prefix, s.Name, fieldName(s.Fields[j]))
+		}
+	}
+	for i := range f.Methods {
+		m := &f.Methods[i]
+		if recv := TypeName(m.Receiver.Type); recv != nil {
+			x.add(pkg, m, prefix, *recv, m.Name)
+		}
+	}
+}
+
+func (x *Index) add(pkg *Package, node Node, prefix string, names ...string) {
+	for _, name := range names {
+		if name == "" || name == "_" {
+			return
+		}
+	}
+	if len(names) == 1 && names[0] == "init" {
+		if _, ok := node.(*Function); ok {
+			return
+		}
+	}
+	id := QualifiedName(prefix, names...)
+	if _, ok := x.nodes[id]; ok {
+		return
+	}
+	x.ids[node] = id
+	x.nodes[id] = node
+	x.packages[node] = pkg
+}
+
+// Returns qualified name of indexed entity or empty string, when entity is not indexed.
+func (x *Index) QualifiedName(node Node) string {
+	return x.ids[node]
+}
+
+// Returns entity by its qualified name or nil.
+func (x *Index) Lookup(id string) Node {
+	return x.nodes[id]
+}
+
+// Returns package, where entity is declared, or nil.
+func (x *Index) Package(node Node) *Package {
+	return x.packages[node]
+}
+
+// Returns sorted qualified names of all indexed entities.
+func (x *Index) Names() []string {
+	ids := make([]string, 0, len(x.nodes))
+	for id := range x.nodes {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
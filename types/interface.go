@@ -7,11 +7,15 @@ import (
 
 type Interface struct {
 	Base
-	Methods []*Function `json:"methods,omitempty"`
+	Methods  []*Function `json:"methods,omitempty"`
+	Embedded []Type      `json:"embedded,omitempty"` // Embedded interfaces.
 }
 
 func (i Interface) String() string {
 	var methods []string
+	for _, e := range i.Embedded {
+		methods = append(methods, e.String())
+	}
 	for _, m := range i.Methods {
 		methods = append(methods, m.funcStr())
 	}
@@ -0,0 +1,414 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Values of `kind` field, which is emitted for every Type in JSON.
+const (
+	KindName      = "name"
+	KindPointer   = "pointer"
+	KindArray     = "array"
+	KindMap       = "map"
+	KindInterface = "interface"
+	KindImport    = "import"
+	KindEllipsis  = "ellipsis"
+	KindChan      = "chan"
+)
+
+// Values of `direction` field of channel type.
+const (
+	ChanDirSendName = "send"
+	ChanDirRecvName = "recv"
+	ChanDirAnyName  = "both"
+)
+
+var chanDirNames = map[int]string{
+	ChanDirSend: ChanDirSendName,
+	ChanDirRecv: ChanDirRecvName,
+	ChanDirAny:  ChanDirAnyName,
+}
+
+var chanDirByName = map[string]int{
+	ChanDirSendName: ChanDirSend,
+	ChanDirRecvName: ChanDirRecv,
+	ChanDirAnyName:  ChanDirAny,
+}
+
+// Version of JSON representation of the model, emitted as `schema_version` of File.
+// Minor version is increased for backward compatible changes, major for incompatible.
+const SchemaVersion = "1.4"
+
+func (f File) MarshalJSON() ([]byte, error) {
+	type alias File
+	return json.Marshal(struct {
+		SchemaVersion string `json:"schema_version"`
+		alias
+	}{SchemaVersion, alias(f)})
+}
+
+func (t TName) MarshalJSON() ([]byte, error) {
+	type alias TName
+	return json.Marshal(struct {
+		Kind string `json:"kind"`
+		alias
+	}{KindName, alias(t)})
+}
+
+func (t TPointer) MarshalJSON() ([]byte, error) {
+	type alias TPointer
+	return json.Marshal(struct {
+		Kind string `json:"kind"`
+		alias
+	}{KindPointer, alias(t)})
+}
+
+func (t *TPointer) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		NumberOfPointers int             `json:"number_of_pointers"`
+		Next             json.RawMessage `json:"next"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	next, err := unmarshalType(raw.Next)
+	if err != nil {
+		return err
+	}
+	*t = TPointer{NumberOfPointers: raw.NumberOfPointers, Next: next}
+	return nil
+}
+
+func (t TArray) MarshalJSON() ([]byte, error) {
+	type alias TArray
+	return json.Marshal(struct {
+		Kind string `json:"kind"`
+		alias
+	}{KindArray, alias(t)})
+}
+
+func (t *TArray) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		ArrayLen   int             `json:"array_len"`
+		IsSlice    bool            `json:"is_slice"`
+		IsEllipsis bool            `json:"is_ellipsis"`
+		Next       json.RawMessage `json:"next"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	next, err := unmarshalType(raw.Next)
+	if err != nil {
+		return err
+	}
+	*t = TArray{ArrayLen: raw.ArrayLen, IsSlice: raw.IsSlice, IsEllipsis: raw.IsEllipsis, Next: next}
+	return nil
+}
+
+func (t TMap) MarshalJSON() ([]byte, error) {
+	type alias TMap
+	return json.Marshal(struct {
+		Kind string `json:"kind"`
+		alias
+	}{KindMap, alias(t)})
+}
+
+func (t *TMap) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Key   json.RawMessage `json:"key"`
+		Value json.RawMessage `json:"value"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	key, err := unmarshalType(raw.Key)
+	if err != nil {
+		return err
+	}
+	value, err := unmarshalType(raw.Value)
+	if err != nil {
+		return err
+	}
+	*t = TMap{Key: key, Value: value}
+	return nil
+}
+
+func (t TInterface) MarshalJSON() ([]byte, error) {
+	type alias TInterface
+	return json.Marshal(struct {
+		Kind string `json:"kind"`
+		alias
+	}{KindInterface, alias(t)})
+}
+
+func (t TImport) MarshalJSON() ([]byte, error) {
+	type alias TImport
+	return json.Marshal(struct {
+		Kind string `json:"kind"`
+		alias
+	}{KindImport, alias(t)})
+}
+
+func (t *TImport) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Import *Import         `json:"import"`
+		Next   json.RawMessage `json:"next"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	next, err := unmarshalType(raw.Next)
+	if err != nil {
+		return err
+	}
+	*t = TImport{Import: raw.Import, Next: next}
+	return nil
+}
+
+func (t TEllipsis) MarshalJSON() ([]byte, error) {
+	type alias TEllipsis
+	return json.Marshal(struct {
+		Kind string `json:"kind"`
+		alias
+	}{KindEllipsis, alias(t)})
+}
+
+func (t *TEllipsis) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Next json.RawMessage `json:"next"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	next, err := unmarshalType(raw.Next)
+	if err != nil {
+		return err
+	}
+	*t = TEllipsis{Next: next}
+	return nil
+}
+
+func (t TChan) MarshalJSON() ([]byte, error) {
+	dir, ok := chanDirNames[t.Direction]
+	if !ok {
+		return nil, fmt.Errorf("unknown channel direction %d", t.Direction)
+	}
+	return json.Marshal(struct {
+		Kind      string `json:"kind"`
+		Direction string `json:"direction"`
+		Next      Type   `json:"next,omitempty"`
+	}{KindChan, dir, t.Next})
+}
+
+func (t *TChan) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Direction string          `json:"direction"`
+		Next      json.RawMessage `json:"next"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	dir, ok := chanDirByName[raw.Direction]
+	if !ok {
+		return fmt.Errorf("unknown channel direction %q", raw.Direction)
+	}
+	next, err := unmarshalType(raw.Next)
+	if err != nil {
+		return err
+	}
+	*t = TChan{Direction: dir, Next: next}
+	return nil
+}
+
+// Variable always emits name, so anonymous params and embedded fields have empty name.
+func (v Variable) MarshalJSON() ([]byte, error) {
+	type alias Variable
+	return json.Marshal(struct {
+		Name string `json:"name"`
+		alias
+	}{v.Name, alias(v)})
+}
+
+func (v *Variable) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Base
+		Type       json.RawMessage `json:"type"`
+		Value      string          `json:"value"`
+		ValueIndex int             `json:"value_index"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	t, err := unmarshalType(raw.Type)
+	if err != nil {
+		return err
+	}
+	*v = Variable{Base: raw.Base, Type: t, Value: raw.Value, ValueIndex: raw.ValueIndex}
+	return nil
+}
+
+// StructField should have own methods, because promoted from Variable methods ignore tags.
+func (f StructField) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Name string `json:"name"`
+		Base
+		Type    Type                `json:"type,omitempty"`
+		Value   string              `json:"value,omitempty"`
+		Tags    map[string][]string `json:"tags,omitempty"`
+		RawTags string              `json:"raw,omitempty"`
+	}{f.Name, f.Base, f.Type, f.Value, f.Tags, f.RawTags})
+}
+
+func (f *StructField) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Tags    map[string][]string `json:"tags"`
+		RawTags string              `json:"raw"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if err := f.Variable.UnmarshalJSON(data); err != nil {
+		return err
+	}
+	f.Tags, f.RawTags = raw.Tags, raw.RawTags
+	return nil
+}
+
+func (i *Interface) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Base
+		Methods  []*Function       `json:"methods"`
+		Embedded []json.RawMessage `json:"embedded"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	var embedded []Type
+	for _, e := range raw.Embedded {
+		t, err := unmarshalType(e)
+		if err != nil {
+			return err
+		}
+		embedded = append(embedded, t)
+	}
+	*i = Interface{Base: raw.Base, Methods: raw.Methods, Embedded: embedded}
+	return nil
+}
+
+func (t *FileType) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Base
+		Type    json.RawMessage `json:"type"`
+		Methods []*Method       `json:"methods"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	tt, err := unmarshalType(raw.Type)
+	if err != nil {
+		return err
+	}
+	*t = FileType{Base: raw.Base, Type: tt, Methods: raw.Methods}
+	return nil
+}
+
+// Decodes Type, using its `kind` field to choose concrete type.
+// Returns nil Type for empty data or null.
+func unmarshalType(data json.RawMessage) (Type, error) {
+	if len(data) == 0 || string(data) == "null" {
+		return nil, nil
+	}
+	var k struct {
+		Kind string `json:"kind"`
+	}
+	if err := json.Unmarshal(data, &k); err != nil {
+		return nil, err
+	}
+	var (
+		t   Type
+		err error
+	)
+	switch k.Kind {
+	case KindName:
+		var x TName
+		err = json.Unmarshal(data, &x)
+		t = x
+	case KindPointer:
+		var x TPointer
+		err = json.Unmarshal(data, &x)
+		t = x
+	case KindArray:
+		var x TArray
+		err = json.Unmarshal(data, &x)
+		t = x
+	case KindMap:
+		var x TMap
+		err = json.Unmarshal(data, &x)
+		t = x
+	case KindInterface:
+		var x TInterface
+		err = json.Unmarshal(data, &x)
+		t = x
+	case KindImport:
+		var x TImport
+		err = json.Unmarshal(data, &x)
+		t = x
+	case KindEllipsis:
+		var x TEllipsis
+		err = json.Unmarshal(data, &x)
+		t = x
+	case KindChan:
+		var x TChan
+		err = json.Unmarshal(data, &x)
+		t = x
+	default:
+		return nil, fmt.Errorf("unknown kind of type %q", k.Kind)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Restores links between structures, types and their methods after decoding.
+// Files of other major schema version are rejected.
+func (f *File) UnmarshalJSON(data []byte) error {
+	type alias File
+	var raw struct {
+		SchemaVersion string `json:"schema_version"`
+		alias
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if raw.SchemaVersion != "" && majorVersion(raw.SchemaVersion) != majorVersion(SchemaVersion) {
+		return fmt.Errorf("unsupported schema version %s, expected %s", raw.SchemaVersion, SchemaVersion)
+	}
+	*f = File(raw.alias)
+	for i := range f.Structures {
+		f.Structures[i].Methods = f.linkMethods(f.Structures[i].Name, f.Structures[i].Methods)
+	}
+	for i := range f.Types {
+		f.Types[i].Methods = f.linkMethods(f.Types[i].Name, f.Types[i].Methods)
+	}
+	return nil
+}
+
+func majorVersion(v string) string {
+	return strings.SplitN(v, ".", 2)[0]
+}
+
+func (f *File) linkMethods(recv string, methods []*Method) []*Method {
+	for i, m := range methods {
+		for j := range f.Methods {
+			name := TypeName(f.Methods[j].Receiver.Type)
+			if name != nil && *name == recv && f.Methods[j].Name == m.Name {
+				methods[i] = &f.Methods[j]
+				break
+			}
+		}
+	}
+	return methods
+}
@@ -0,0 +1,268 @@
+package types
+
+import "sort"
+
+// Selection is a method of method set.
+type Selection struct {
+	Method *Function
+	// Names of embedded fields or interfaces, through which method is promoted. It is empty for own methods.
+	Path []string
+	// Import path of package, where method is declared, when it is promoted from type of other package.
+	// Unqualified types of such method are declared in that package. It is empty for methods of the same package.
+	Package string
+	// Method is declared with pointer receiver.
+	PointerReceiver bool
+}
+
+// MethodSet contains method sets of named type T and pointer to it.
+type MethodSet struct {
+	Value   []Selection // Method set of T.
+	Pointer []Selection // Method set of *T.
+	// Names of selectors, which are promoted from several embedded fields at the same depth.
+	// Such methods are not included into method sets.
+	Ambiguous []string
+}
+
+// Importer loads packages by their import paths.
+type Importer interface {
+	Import(path string) (*Package, error)
+}
+
+// Returns resolver, which finds types, declared in files of package.
+// Types, qualified by imports, are found in packages, loaded by imp, which may be nil.
+func (p *Package) Scope(imp Importer) Resolver {
+	return packageScope{pkg: p, imp: imp}
+}
+
+type packageScope struct {
+	pkg *Package
+	imp Importer
+}
+
+func (s packageScope) Resolve(t Type) (interface{}, Resolver) {
+	switch tt := t.(type) {
+	case TName:
+		for _, f := range s.pkg.Files {
+			if decl, _ := f.Resolve(tt); decl != nil {
+				return decl, s
+			}
+		}
+	case TImport:
+		if s.imp == nil || tt.Import == nil {
+			return nil, nil
+		}
+		pkg, err := s.imp.Import(tt.Import.Package)
+		if err != nil {
+			return nil, nil
+		}
+		return pkg.Scope(s.imp).Resolve(tt.Next)
+	}
+	return nil, nil
+}
+
+// Returns method sets of structure, including methods, promoted from embedded fields.
+// Embedded types are found by resolver r, which may be nil: then only own methods are returned.
+func (s *Struct) MethodSet(r Resolver) MethodSet {
+	return methodSet(s, r)
+}
+
+// Returns method sets of named type. Defined types do not inherit methods, so only own methods are returned.
+func (t *FileType) MethodSet(r Resolver) MethodSet {
+	return methodSet(t, r)
+}
+
+// Returns all methods of interface, including methods of embedded interfaces.
+// Embedded interfaces are found by resolver r, which may be nil.
+// Use Selections to get packages, where promoted methods are declared.
+func (i *Interface) AllMethods(r Resolver) []*Function {
+	var methods []*Function
+	for _, sel := range i.Selections(r) {
+		methods = append(methods, sel.Method)
+	}
+	return methods
+}
+
+// Returns all methods of interface, including methods of embedded interfaces, with names of embedded
+// interfaces, through which they are promoted, and packages, where they are declared.
+// Embedded interfaces are found by resolver r, which may be nil.
+func (i *Interface) Selections(r Resolver) []Selection {
+	return interfaceMethods(i, r, "", nil, make(map[*Interface]bool))
+}
+
+func interfaceMethods(i *Interface, r Resolver, pkg string, path []string, seen map[*Interface]bool) []Selection {
+	if seen[i] {
+		return nil
+	}
+	seen[i] = true
+	var sels []Selection
+	for _, m := range i.Methods {
+		sels = append(sels, Selection{Method: m, Path: path, Package: pkg})
+	}
+	for _, e := range i.Embedded {
+		var (
+			embedded *Interface
+			scope    = r
+			epkg     = pkg
+		)
+		if r != nil {
+			decl, sc := r.Resolve(e)
+			if iface, ok := decl.(*Interface); ok {
+				embedded, scope = iface, sc
+			}
+		}
+		if embedded == nil {
+			embedded = interfaceOf(e, nil)
+		}
+		if embedded == nil {
+			continue
+		}
+		if imp, ok := e.(TImport); ok && imp.Import != nil {
+			epkg = imp.Import.Package
+		}
+		epath := path
+		if name := TypeName(e); name != nil {
+			epath = append(append([]string(nil), path...), *name)
+		}
+	next:
+		for _, sel := range interfaceMethods(embedded, scope, epkg, epath, seen) {
+			// Identical methods of several embedded interfaces are allowed.
+			for _, own := range sels {
+				if own.Method.Name == sel.Method.Name {
+					continue next
+				}
+			}
+			sels = append(sels, sel)
+		}
+	}
+	return sels
+}
+
+// Embedded type, which is visited during method set computation.
+type embedding struct {
+	decl  interface{}
+	scope Resolver
+	path  []string
+	// Import path of package of declaration, when it is not the package of the type itself.
+	pkg string
+	// Some field on the path is embedded by pointer, so methods with pointer receivers
+	// are promoted to value method set too.
+	indirect bool
+}
+
+// Candidate for selector of some name at one depth.
+type candidate struct {
+	method   *Function
+	pointer  bool
+	path     []string
+	pkg      string
+	indirect bool
+}
+
+// Computes method sets by breadth-first search over embedded fields, as described in Go spec:
+// selectors of lower depth shadow deeper ones, several selectors at the same depth are ambiguous.
+func methodSet(decl interface{}, r Resolver) MethodSet {
+	var (
+		ms      MethodSet
+		current = []embedding{{decl: decl, scope: r}}
+		found   = make(map[string]bool)
+		seen    = make(map[interface{}]bool)
+	)
+	for len(current) > 0 {
+		candidates := make(map[string][]candidate)
+		var next []embedding
+		for _, e := range current {
+			// The same type, embedded several times at one depth, produces ambiguous selectors,
+			// so types are marked as seen only after the whole depth is processed.
+			if seen[e.decl] {
+				continue
+			}
+			switch d := e.decl.(type) {
+			case *Interface:
+				for _, sel := range interfaceMethods(d, e.scope, e.pkg, nil, make(map[*Interface]bool)) {
+					m := sel.Method
+					candidates[m.Name] = append(candidates[m.Name], candidate{method: m, path: e.path, pkg: sel.Package, indirect: e.indirect})
+				}
+			case *FileType:
+				for _, m := range d.Methods {
+					candidates[m.Name] = append(candidates[m.Name], candidate{method: &m.Function, pointer: isPointerReceiver(m), path: e.path, pkg: e.pkg, indirect: e.indirect})
+				}
+			case *Struct:
+				for _, m := range d.Methods {
+					candidates[m.Name] = append(candidates[m.Name], candidate{method: &m.Function, pointer: isPointerReceiver(m), path: e.path, pkg: e.pkg, indirect: e.indirect})
+				}
+				for _, f := range d.Fields {
+					name := fieldName(f)
+					// Fields are selectors too, they shadow methods.
+					candidates[name] = append(candidates[name], candidate{path: e.path})
+					if f.Name != "" || e.scope == nil {
+						continue
+					}
+					t, indirect := f.Type, e.indirect
+					if p, ok := t.(TPointer); ok && p.NumberOfPointers == 1 {
+						t, indirect = p.Next, true
+					}
+					embedded, scope := e.scope.Resolve(t)
+					if embedded == nil {
+						if iface := interfaceOf(t, nil); iface != nil {
+							embedded, scope = iface, e.scope
+						}
+					}
+					if embedded != nil {
+						pkg := e.pkg
+						if imp, ok := t.(TImport); ok && imp.Import != nil {
+							pkg = imp.Import.Package
+						}
+						next = append(next, embedding{
+							decl:     embedded,
+							scope:    scope,
+							path:     append(append([]string(nil), e.path...), name),
+							pkg:      pkg,
+							indirect: indirect,
+						})
+					}
+				}
+			}
+		}
+		names := make([]string, 0, len(candidates))
+		for name := range candidates {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if found[name] {
+				continue
+			}
+			found[name] = true
+			cs := candidates[name]
+			if len(cs) > 1 {
+				ms.Ambiguous = append(ms.Ambiguous, name)
+				continue
+			}
+			c := cs[0]
+			if c.method == nil {
+				continue
+			}
+			sel := Selection{Method: c.method, Path: c.path, Package: c.pkg, PointerReceiver: c.pointer}
+			ms.Pointer = append(ms.Pointer, sel)
+			if !c.pointer || c.indirect {
+				ms.Value = append(ms.Value, sel)
+			}
+		}
+		for _, e := range current {
+			seen[e.decl] = true
+		}
+		current = next
+	}
+	return ms
+}
+
+// Returns name of field or name of embedded type.
+func fieldName(f StructField) string {
+	if f.Name != "" {
+		return f.Name
+	}
+	if name := TypeName(f.Type); name != nil {
+		return *name
+	}
+	return ""
+}
@@ -0,0 +1,8 @@
+package types
+
+// Package is a set of files of one Go package.
+type Package struct {
+	Base          // `Package.Name` is package name.
+	Path  string  `json:"path,omitempty"` // Import path of package.
+	Files []*File `json:"files,omitempty"`
+}
@@ -0,0 +1,213 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strconv"
+	"strings"
+)
+
+// ErrUnexpectedSpec is returned for expressions, which are not supported types, e.g. func or struct types.
+var ErrUnexpectedSpec = errors.New("unexpected spec")
+
+// Finds import by package name, used in qualified type.
+type ImportFinder func(name string) (*Import, error)
+
+// Parses type expression, e.g. `map[string][]*models.User` or `chan<- error`, and returns
+// the same model, as parser of files does. Packages of qualified types are found in imports
+// by their names or, when import has no name, by package name, assumed by import path.
+// Variadic types are written as `...T`.
+func ParseTypeString(expr string, imports []Import) (Type, error) {
+	src := strings.TrimSpace(expr)
+	variadic := strings.HasPrefix(src, "...")
+	node, err := parser.ParseExpr(strings.TrimPrefix(src, "..."))
+	if err != nil {
+		return nil, fmt.Errorf("can't parse type %q: %v", expr, err)
+	}
+	find := func(name string) (*Import, error) {
+		if im := findImport(imports, name); im != nil {
+			return im, nil
+		}
+		return nil, fmt.Errorf("could not resolve package %s", name)
+	}
+	t, err := TypeOfExpr(node, find)
+	if err != nil {
+		return nil, fmt.Errorf("can't parse type %q: %v", expr, err)
+	}
+	if variadic {
+		t = TEllipsis{Next: t}
+	}
+	return t, nil
+}
+
+// Returns model of type expression. Packages of qualified types are found by find.
+// Lengths of arrays, which are not integer literals, can't be evaluated, so such arrays are treated as slices.
+func TypeOfExpr(expr ast.Expr, find ImportFinder) (Type, error) {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return TName{TypeName: t.Name}, nil
+	case *ast.SelectorExpr:
+		x, ok := t.X.(*ast.Ident)
+		if !ok {
+			return nil, fmt.Errorf("%v: %T", ErrUnexpectedSpec, t.X)
+		}
+		im, err := find(x.Name)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", t.Sel.Name, err)
+		}
+		if im == nil {
+			return nil, fmt.Errorf("wrong import %d:%d", t.Pos(), t.End())
+		}
+		return TImport{Import: im, Next: TName{TypeName: t.Sel.Name}}, nil
+	case *ast.StarExpr:
+		next, err := TypeOfExpr(t.X, find)
+		if err != nil {
+			return nil, err
+		}
+		if p, ok := next.(TPointer); ok {
+			return TPointer{Next: p.Next, NumberOfPointers: 1 + p.NumberOfPointers}, nil
+		}
+		return TPointer{Next: next, NumberOfPointers: 1}, nil
+	case *ast.ArrayType:
+		next, err := TypeOfExpr(t.Elt, find)
+		if err != nil {
+			return nil, err
+		}
+		switch l := t.Len.(type) {
+		case nil:
+			return TArray{Next: next, IsSlice: true}, nil
+		case *ast.Ellipsis:
+			return TArray{Next: next, IsEllipsis: true}, nil
+		case *ast.BasicLit:
+			n, err := strconv.ParseInt(l.Value, 0, 0)
+			if l.Kind != token.INT || err != nil {
+				return nil, fmt.Errorf("wrong array length %s", l.Value)
+			}
+			return TArray{Next: next, ArrayLen: int(n)}, nil
+		default:
+			return TArray{Next: next, IsSlice: true}, nil
+		}
+	case *ast.MapType:
+		key, err := TypeOfExpr(t.Key, find)
+		if err != nil {
+			return nil, err
+		}
+		value, err := TypeOfExpr(t.Value, find)
+		if err != nil {
+			return nil, err
+		}
+		return TMap{Key: key, Value: value}, nil
+	case *ast.InterfaceType:
+		iface, err := InterfaceOfExpr(t, find)
+		if err != nil {
+			return nil, err
+		}
+		return TInterface{Interface: iface}, nil
+	case *ast.Ellipsis:
+		next, err := TypeOfExpr(t.Elt, find)
+		if err != nil {
+			return nil, err
+		}
+		return TEllipsis{Next: next}, nil
+	case *ast.ChanType:
+		next, err := TypeOfExpr(t.Value, find)
+		if err != nil {
+			return nil, err
+		}
+		return TChan{Next: next, Direction: int(t.Dir)}, nil
+	case *ast.ParenExpr:
+		return TypeOfExpr(t.X, find)
+	case *ast.BadExpr:
+		return nil, fmt.Errorf("bad expression")
+	}
+	return nil, fmt.Errorf("%v: %T", ErrUnexpectedSpec, expr)
+}
+
+// Returns model of interface type with methods and embedded interfaces.
+// Name and docs of interface are not set.
+func InterfaceOfExpr(t *ast.InterfaceType, find ImportFinder) (*Interface, error) {
+	iface := &Interface{}
+	if t.Methods == nil {
+		return iface, nil
+	}
+	for _, field := range t.Methods.List {
+		if len(field.Names) == 0 {
+			embedded, err := TypeOfExpr(field.Type, find)
+			if err != nil {
+				return nil, fmt.Errorf("can't parse embedded interface: %v", err)
+			}
+			iface.Embedded = append(iface.Embedded, embedded)
+			continue
+		}
+		fn := &Function{Base: Base{Name: field.Names[0].Name, Docs: commentsOf(field.Doc)}}
+		funcType := field.Type.(*ast.FuncType)
+		var err error
+		if fn.Args, err = ParamsOfExpr(funcType.Params, find); err != nil {
+			return nil, fmt.Errorf("can't parse args: %v", err)
+		}
+		if fn.Results, err = ParamsOfExpr(funcType.Results, find); err != nil {
+			return nil, fmt.Errorf("can't parse results: %v", err)
+		}
+		iface.Methods = append(iface.Methods, fn)
+	}
+	return iface, nil
+}
+
+// Returns params of function, receiver or fields of structure. Every name of field is a separate variable.
+func ParamsOfExpr(fields *ast.FieldList, find ImportFinder) ([]Variable, error) {
+	if fields == nil {
+		return nil, nil
+	}
+	var vars []Variable
+	for _, field := range fields.List {
+		if field.Type == nil {
+			return nil, fmt.Errorf("param's type is nil %d:%d", field.Pos(), field.End())
+		}
+		t, err := TypeOfExpr(field.Type, find)
+		if err != nil {
+			var names []string
+			for _, name := range field.Names {
+				names = append(names, name.Name)
+			}
+			return nil, fmt.Errorf("wrong type of %s: %v", strings.Join(names, ","), err)
+		}
+		docs := commentsOf(field.Doc)
+		if len(field.Names) == 0 {
+			vars = append(vars, Variable{Base: Base{Docs: docs}, Type: t})
+		}
+		for _, name := range field.Names {
+			vars = append(vars, Variable{Base: Base{Name: name.Name, Docs: docs}, Type: t})
+		}
+	}
+	return vars, nil
+}
+
+func commentsOf(group *ast.CommentGroup) []string {
+	if group == nil {
+		return nil
+	}
+	var comments []string
+	for _, comment := range group.List {
+		comments = append(comments, comment.Text)
+	}
+	return comments
+}
+
+// Returns copy of import with name alias or, when it is not found, of import with default name alias.
+func findImport(imports []Import, alias string) *Import {
+	for _, im := range imports {
+		if im.Name == alias {
+			return &im
+		}
+	}
+	for _, im := range imports {
+		if im.Name == "" && !im.Dot && !im.Blank && AssumedPackageName(im.Package) == alias {
+			im.Name = alias
+			return &im
+		}
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package types
+
+// Returns copy of t, where every node, for which fn returns true, is replaced by returned type.
+// fn is called for nodes in pre-order, replaced nodes are not visited deeper.
+// Original type is not modified, so it is safe to rewrite types, which share nodes.
+func Rewrite(t Type, fn func(Type) (Type, bool)) Type {
+	if t == nil {
+		return nil
+	}
+	if nt, ok := fn(t); ok {
+		return nt
+	}
+	switch tt := t.(type) {
+	case TPointer:
+		tt.Next = Rewrite(tt.Next, fn)
+		// Keep pointers collapsed, as parser does.
+		if next, ok := tt.Next.(TPointer); ok {
+			tt.NumberOfPointers += next.NumberOfPointers
+			tt.Next = next.Next
+		}
+		return tt
+	case TArray:
+		tt.Next = Rewrite(tt.Next, fn)
+		return tt
+	case TMap:
+		tt.Key = Rewrite(tt.Key, fn)
+		tt.Value = Rewrite(tt.Value, fn)
+		return tt
+	case TInterface:
+		if tt.Interface != nil {
+			tt.Interface = RewriteInterface(tt.Interface, fn)
+		}
+		return tt
+	case TImport:
+		tt.Next = Rewrite(tt.Next, fn)
+		return tt
+	case TEllipsis:
+		tt.Next = Rewrite(tt.Next, fn)
+		return tt
+	case TChan:
+		tt.Next = Rewrite(tt.Next, fn)
+		return tt
+	}
+	return t
+}
+
+// Returns copy of interface with rewritten types of methods.
+func RewriteInterface(i *Interface, fn func(Type) (Type, bool)) *Interface {
+	ni := *i
+	if i.Methods != nil {
+		ni.Methods = make([]*Function, len(i.Methods))
+	}
+	for j, m := range i.Methods {
+		ni.Methods[j] = RewriteFunction(m, fn)
+	}
+	if i.Embedded != nil {
+		ni.Embedded = make([]Type, len(i.Embedded))
+		for j, e := range i.Embedded {
+			ni.Embedded[j] = Rewrite(e, fn)
+		}
+	}
+	return &ni
+}
+
+// Returns copy of function with rewritten types of args and results.
+func RewriteFunction(f *Function, fn func(Type) (Type, bool)) *Function {
+	nf := *f
+	nf.Args = rewriteVariables(f.Args, fn)
+	nf.Results = rewriteVariables(f.Results, fn)
+	return &nf
+}
+
+// Returns copy of structure with rewritten types of fields.
+// Methods of structure are shared with the original.
+func RewriteStruct(s *Struct, fn func(Type) (Type, bool)) *Struct {
+	ns := *s
+	if s.Fields != nil {
+		ns.Fields = make([]StructField, len(s.Fields))
+		for i, f := range s.Fields {
+			f.Type = Rewrite(f.Type, fn)
+			ns.Fields[i] = f
+		}
+	}
+	return &ns
+}
+
+// Returns deep copy of file with all types rewritten.
+// Structures and types of the copy are linked with methods of the copy.
+func RewriteFile(f *File, fn func(Type) (Type, bool)) *File {
+	nf := *f
+	nf.Imports = append([]Import(nil), f.Imports...)
+	nf.Constants = rewriteVariables(f.Constants, fn)
+	nf.Vars = rewriteVariables(f.Vars, fn)
+	if f.Interfaces != nil {
+		nf.Interfaces = make([]Interface, len(f.Interfaces))
+		for i := range f.Interfaces {
+			nf.Interfaces[i] = *RewriteInterface(&f.Interfaces[i], fn)
+		}
+	}
+	if f.Functions != nil {
+		nf.Functions = make([]Function, len(f.Functions))
+		for i := range f.Functions {
+			nf.Functions[i] = *RewriteFunction(&f.Functions[i], fn)
+		}
+	}
+	methods := make(map[*Method]*Method)
+	if f.Methods != nil {
+		nf.Methods = make([]Method, len(f.Methods))
+		for i, m := range f.Methods {
+			m.Function = *RewriteFunction(&m.Function, fn)
+			m.Receiver.Type = Rewrite(m.Receiver.Type, fn)
+			nf.Methods[i] = m
+			methods[&f.Methods[i]] = &nf.Methods[i]
+		}
+	}
+	if f.Structures != nil {
+		nf.Structures = make([]Struct, len(f.Structures))
+		for i := range f.Structures {
+			s := RewriteStruct(&f.Structures[i], fn)
+			s.Methods = relinkMethods(s.Methods, methods)
+			nf.Structures[i] = *s
+		}
+	}
+	if f.Types != nil {
+		nf.Types = make([]FileType, len(f.Types))
+		for i, t := range f.Types {
+			t.Type = Rewrite(t.Type, fn)
+			t.Methods = relinkMethods(t.Methods, methods)
+			nf.Types[i] = t
+		}
+	}
+	return &nf
+}
+
+func rewriteVariables(vars []Variable, fn func(Type) (Type, bool)) []Variable {
+	if vars == nil {
+		return nil
+	}
+	nvars := make([]Variable, len(vars))
+	for i, v := range vars {
+		v.Type = Rewrite(v.Type, fn)
+		nvars[i] = v
+	}
+	return nvars
+}
+
+// Methods, which are not found in copied methods, are left as is.
+func relinkMethods(methods []*Method, copies map[*Method]*Method) []*Method {
+	if methods == nil {
+		return nil
+	}
+	linked := make([]*Method, len(methods))
+	for i, m := range methods {
+		if c, ok := copies[m]; ok {
+			linked[i] = c
+		} else {
+			linked[i] = m
+		}
+	}
+	return linked
+}
@@ -0,0 +1,193 @@
+package types
+
+//go:generate go run gen_schema.go
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// Descriptions of schema definitions and their properties, keyed by `Def` or `Def.property`.
+var schemaDescriptions = map[string]string{
+	"File":                        "Top-level declarations of one Go file.",
+	"File.schema_version":         "Version of this schema, the document was produced with.",
+	"File.name":                   "Package name.",
+	"File.docs":                   "Comments above package clause.",
+	"Import":                      "Imported package.",
+	"Import.name":                 "Alias of the package, or its name when alias is omitted.",
+	"Import.package":              "Import path.",
+	"Import.dot":                  "Package is imported with `.` name, its identifiers are used unqualified.",
+	"Import.blank":                "Package is imported with `_` name for side effects.",
+	"Import.cgo":                  "Pseudo-package `C`, types of which are C types.",
+	"Variable":                    "Constant, variable, function parameter or result.",
+	"Variable.name":               "Name of variable, empty for anonymous parameters.",
+	"Variable.value":              "Source of value expression of constant or variable.",
+	"Variable.value_index":        "Index of result of multi-valued value, which is assigned to variable, e.g. 1 for `b` of `var a, b = f()`.",
+	"StructField":                 "Field of structure.",
+	"StructField.name":            "Name of field, empty for embedded fields.",
+	"StructField.tags":            "Parsed tags, keyed by tag key. First value is a name, others are options.",
+	"StructField.raw":             "Raw tags string from source, including quotes.",
+	"Struct":                      "Declaration `type Foo struct`.",
+	"Interface":                   "Declaration `type Foo interface`.",
+	"Interface.embedded":          "Embedded interfaces.",
+	"Function":                    "Function or interface method.",
+	"Method":                      "Function with receiver.",
+	"FileType":                    "Declaration of any other named type, e.g. `type Foo int`.",
+	"Type":                        "Type expression. Concrete type is chosen by `kind` field.",
+	"TName":                       "Named type, e.g. `int` or `Foo`.",
+	"TPointer":                    "Pointer type, e.g. `**Foo`.",
+	"TPointer.number_of_pointers": "Number of stars.",
+	"TArray":                      "Array or slice type.",
+	"TArray.array_len":            "Length of array.",
+	"TArray.is_slice":             "Type is a slice.",
+	"TArray.is_ellipsis":          "Array declared with `[...]`.",
+	"TMap":                        "Map type.",
+	"TInterface":                  "Interface type literal.",
+	"TImport":                     "Type, qualified by imported package, e.g. `io.Writer`.",
+	"TEllipsis":                   "Variadic parameter type, e.g. `...int`.",
+	"TChan":                       "Channel type.",
+	"TChan.direction":             "Direction of channel.",
+}
+
+var typeKinds = []struct {
+	kind string
+	typ  reflect.Type
+}{
+	{KindName, reflect.TypeOf(TName{})},
+	{KindPointer, reflect.TypeOf(TPointer{})},
+	{KindArray, reflect.TypeOf(TArray{})},
+	{KindMap, reflect.TypeOf(TMap{})},
+	{KindInterface, reflect.TypeOf(TInterface{})},
+	{KindImport, reflect.TypeOf(TImport{})},
+	{KindEllipsis, reflect.TypeOf(TEllipsis{})},
+	{KindChan, reflect.TypeOf(TChan{})},
+}
+
+var typeOfType = reflect.TypeOf((*Type)(nil)).Elem()
+
+// Returns JSON Schema (draft 2020-12) document, which describes JSON representation of File.
+// Document is produced from Go types of this package.
+func JSONSchema() ([]byte, error) {
+	b := schemaBuilder{defs: make(map[string]map[string]interface{})}
+	b.schemaOf(reflect.TypeOf(File{}))
+	doc := map[string]interface{}{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"$id":     "https://github.com/vetcher/godecl/types/schema.json",
+		"title":   "godecl declarations model, schema version " + SchemaVersion,
+		"$ref":    "#/$defs/File",
+		"$defs":   b.defs,
+	}
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+type schemaBuilder struct {
+	defs map[string]map[string]interface{}
+}
+
+func (b *schemaBuilder) schemaOf(t reflect.Type) map[string]interface{} {
+	if t == typeOfType {
+		return b.typeSchema()
+	}
+	switch t.Kind() {
+	case reflect.Ptr:
+		return b.schemaOf(t.Elem())
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Slice:
+		return map[string]interface{}{"type": "array", "items": b.schemaOf(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": b.schemaOf(t.Elem())}
+	case reflect.Struct:
+		return b.structSchema(t)
+	}
+	return map[string]interface{}{}
+}
+
+func (b *schemaBuilder) typeSchema() map[string]interface{} {
+	const name = "Type"
+	if _, ok := b.defs[name]; !ok {
+		def := map[string]interface{}{"description": schemaDescriptions[name]}
+		b.defs[name] = def
+		var oneOf []interface{}
+		for _, k := range typeKinds {
+			oneOf = append(oneOf, b.schemaOf(k.typ))
+		}
+		def["oneOf"] = oneOf
+	}
+	return ref(name)
+}
+
+func (b *schemaBuilder) structSchema(t reflect.Type) map[string]interface{} {
+	name := t.Name()
+	if _, ok := b.defs[name]; ok {
+		return ref(name)
+	}
+	def := map[string]interface{}{"type": "object"}
+	b.defs[name] = def
+	if d, ok := schemaDescriptions[name]; ok {
+		def["description"] = d
+	}
+	props := make(map[string]interface{})
+	var required []string
+	b.fields(t, props, &required)
+	// Properties, which are produced by custom marshallers.
+	for _, k := range typeKinds {
+		if k.typ == t {
+			props["kind"] = map[string]interface{}{"const": k.kind}
+			required = append(required, "kind")
+		}
+	}
+	switch t {
+	case reflect.TypeOf(TChan{}):
+		props["direction"] = map[string]interface{}{
+			"enum": []string{ChanDirSendName, ChanDirRecvName, ChanDirAnyName},
+		}
+	case reflect.TypeOf(Variable{}), reflect.TypeOf(StructField{}):
+		required = append(required, "name")
+	case reflect.TypeOf(File{}):
+		props["schema_version"] = map[string]interface{}{"type": "string"}
+		required = append(required, "schema_version")
+	}
+	for prop, schema := range props {
+		if d, ok := schemaDescriptions[name+"."+prop]; ok {
+			schema.(map[string]interface{})["description"] = d
+		}
+	}
+	def["properties"] = props
+	if len(required) > 0 {
+		def["required"] = required
+	}
+	return ref(name)
+}
+
+// Collects properties of struct fields, flattening embedded structures.
+func (b *schemaBuilder) fields(t reflect.Type, props map[string]interface{}, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := strings.Split(field.Tag.Get("json"), ",")
+		if tag[0] == "-" {
+			continue
+		}
+		if field.Anonymous && tag[0] == "" {
+			b.fields(field.Type, props, required)
+			continue
+		}
+		name := tag[0]
+		if name == "" {
+			name = field.Name
+		}
+		props[name] = b.schemaOf(field.Type)
+		if len(tag) == 1 || tag[1] != "omitempty" {
+			*required = append(*required, name)
+		}
+	}
+}
+
+func ref(name string) map[string]interface{} {
+	return map[string]interface{}{"$ref": "#/$defs/" + name}
+}
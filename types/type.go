@@ -35,11 +35,19 @@ func (TInterface) TypeOf() TypesOfTypes {
 	return T_Interface
 }
 
+// Returns interface literal in one line, e.g. `interface{ io.Reader; Close() error }`.
 func (i TInterface) String() string {
-	if i.Interface != nil {
-		return i.Interface.String()
+	if i.Interface == nil || len(i.Interface.Embedded)+len(i.Interface.Methods) == 0 {
+		return "interface{}"
 	}
-	return ""
+	var elems []string
+	for _, e := range i.Interface.Embedded {
+		elems = append(elems, e.String())
+	}
+	for _, m := range i.Interface.Methods {
+		elems = append(elems, m.funcStr())
+	}
+	return "interface{ " + strings.Join(elems, "; ") + " }"
 }
 
 type TMap struct {
@@ -106,7 +114,7 @@ func (TArray) TypeOf() TypesOfTypes {
 func (i TArray) String() string {
 	str := ""
 	if i.IsEllipsis {
-		str += "..."
+		str += "[...]"
 	} else if i.IsSlice {
 		str += "[]"
 	} else {
@@ -133,7 +141,7 @@ func (TImport) TypeOf() TypesOfTypes {
 
 func (i TImport) String() string {
 	str := ""
-	if i.Import != nil {
+	if i.Import != nil && !i.Import.Dot {
 		str += i.Import.Name + "."
 	}
 	if i.Next != nil {
@@ -174,8 +182,8 @@ const (
 )
 
 type TChan struct {
-	Direction int
-	Next      Type
+	Direction int  `json:"direction"` // One of ChanDir* constants, emitted as string.
+	Next      Type `json:"next,omitempty"`
 }
 
 func (TChan) TypeOf() TypesOfTypes {
@@ -194,8 +202,12 @@ var strForChan = map[int]string{
 
 func (c TChan) String() string {
 	str := strForChan[c.Direction]
-	if c.Next != nil {
-		return str + " " + c.Next.String()
+	if c.Next == nil {
+		return str
 	}
-	return str
+	// `chan <-chan T` is parsed as `chan<- chan T`, so receive-only element needs parens.
+	if next, ok := c.Next.(TChan); ok && c.Direction == ChanDirAny && next.Direction == ChanDirRecv {
+		return str + " (" + next.String() + ")"
+	}
+	return str + " " + c.Next.String()
 }
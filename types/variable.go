@@ -2,11 +2,21 @@ package types
 
 type Variable struct {
 	Base
-	Type Type `json:"type,omitempty"`
+	Type  Type   `json:"type,omitempty"`
+	Value string `json:"value,omitempty"` // Source of value expression of constant or variable, if it is provided.
+	// Index of result of Value, which is assigned to variable, e.g. 1 for `b` of `var a, b = f()`.
+	// Variables of one declaration have the same Value and consecutive indexes.
+	ValueIndex int `json:"value_index,omitempty"`
 }
 
 // String representation of variable without docs
 func (v Variable) String() string {
+	if v.Type == nil {
+		return v.Name
+	}
+	if v.Name == "" {
+		return v.Type.String()
+	}
 	return v.Name + " " + v.Type.String()
 }
 
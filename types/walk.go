@@ -0,0 +1,222 @@
+package types
+
+import "fmt"
+
+// Node is any entity of the model: one of *File, *Import, *Variable, *StructField, *Struct,
+// *Interface, *Function, *Method, *FileType or Type.
+type Node interface{}
+
+// Visitor's Visit method is invoked for each node encountered by Walk.
+// If the result visitor w is not nil, Walk visits each of the children
+// of node with the visitor w, followed by a call of w.Visit(nil).
+type Visitor interface {
+	Visit(node Node) (w Visitor)
+}
+
+// Traverses the model in depth-first order, like ast.Walk does.
+// Methods of structures and types are not children of them: they are visited as children of File.
+func Walk(v Visitor, node Node) {
+	if v = v.Visit(node); v == nil {
+		return
+	}
+	for _, child := range children(node) {
+		Walk(v, child)
+	}
+	v.Visit(nil)
+}
+
+type inspector func(Node) bool
+
+func (f inspector) Visit(node Node) Visitor {
+	if f(node) {
+		return f
+	}
+	return nil
+}
+
+// Traverses the model in depth-first order: it starts by calling f(node);
+// if f returns true, Inspect invokes f recursively for each of the children of node,
+// followed by a call of f(nil).
+func Inspect(node Node, f func(Node) bool) {
+	Walk(inspector(f), node)
+}
+
+// Cursor describes node, visited by Apply.
+type Cursor struct {
+	node, parent Node
+	replaced     bool
+}
+
+// Returns current node.
+func (c *Cursor) Node() Node {
+	return c.node
+}
+
+// Returns parent of current node or nil for the root node.
+func (c *Cursor) Parent() Node {
+	return c.parent
+}
+
+// Replaces current node, which should be a Type, by t. Replace panics for other nodes.
+// Children of new node are visited, when Replace is called by pre.
+func (c *Cursor) Replace(t Type) {
+	if _, ok := c.node.(Type); !ok {
+		panic(fmt.Sprintf("types: Replace of %T", c.node))
+	}
+	c.node, c.replaced = t, true
+}
+
+// Traverses the model in depth-first order, calling pre before visiting children of node and post after.
+// If pre returns false, children of node and post for node are skipped. Both pre and post may be nil.
+// Types, replaced by Cursor.Replace, are assigned to fields of their parents, so the model is changed in place,
+// and types, which contain replaced types, are rebuilt. Returns root node, which may be replaced too.
+// Use Rewrite to get changed copy of the model.
+func Apply(node Node, pre func(*Cursor) bool, post func(*Cursor)) Node {
+	c := &Cursor{node: node}
+	apply(c, pre, post)
+	return c.node
+}
+
+func apply(c *Cursor, pre func(*Cursor) bool, post func(*Cursor)) {
+	if pre != nil && !pre(c) {
+		return
+	}
+	for i, child := range children(c.node) {
+		cc := &Cursor{node: child, parent: c.node}
+		apply(cc, pre, post)
+		if !cc.replaced {
+			continue
+		}
+		c.node = replaceChild(c.node, i, cc.node.(Type))
+		// Types are values, so rebuilt type should be assigned to its parent.
+		if _, ok := c.node.(Type); ok {
+			c.replaced = true
+		}
+	}
+	if post != nil {
+		post(c)
+	}
+}
+
+// Sets i-th child of node, as it is returned by children, to t.
+func replaceChild(node Node, i int, t Type) Node {
+	switch n := node.(type) {
+	case *Variable:
+		n.Type = t
+	case *StructField:
+		n.Type = t
+	case *FileType:
+		n.Type = t
+	case *Interface:
+		n.Embedded[i] = t
+	case TMap:
+		if i == 0 && n.Key != nil {
+			n.Key = t
+		} else {
+			n.Value = t
+		}
+		return n
+	case TPointer:
+		n.Next = t
+		// Keep pointers collapsed, as parser does.
+		if next, ok := t.(TPointer); ok {
+			n.NumberOfPointers += next.NumberOfPointers
+			n.Next = next.Next
+		}
+		return n
+	case TArray:
+		n.Next = t
+		return n
+	case TImport:
+		n.Next = t
+		return n
+	case TEllipsis:
+		n.Next = t
+		return n
+	case TChan:
+		n.Next = t
+		return n
+	}
+	return node
+}
+
+// Returns direct children of node.
+func children(node Node) []Node {
+	var nodes []Node
+	switch n := node.(type) {
+	case *File:
+		for i := range n.Imports {
+			nodes = append(nodes, &n.Imports[i])
+		}
+		for i := range n.Constants {
+			nodes = append(nodes, &n.Constants[i])
+		}
+		for i := range n.Vars {
+			nodes = append(nodes, &n.Vars[i])
+		}
+		for i := range n.Types {
+			nodes = append(nodes, &n.Types[i])
+		}
+		for i := range n.Interfaces {
+			nodes = append(nodes, &n.Interfaces[i])
+		}
+		for i := range n.Structures {
+			nodes = append(nodes, &n.Structures[i])
+		}
+		for i := range n.Functions {
+			nodes = append(nodes, &n.Functions[i])
+		}
+		for i := range n.Methods {
+			nodes = append(nodes, &n.Methods[i])
+		}
+	case *Variable:
+		nodes = appendType(nodes, n.Type)
+	case *StructField:
+		nodes = appendType(nodes, n.Type)
+	case *FileType:
+		nodes = appendType(nodes, n.Type)
+	case *Struct:
+		for i := range n.Fields {
+			nodes = append(nodes, &n.Fields[i])
+		}
+	case *Interface:
+		for _, e := range n.Embedded {
+			nodes = appendType(nodes, e)
+		}
+		for _, m := range n.Methods {
+			nodes = append(nodes, m)
+		}
+	case *Function:
+		nodes = appendParams(nodes, n)
+	case *Method:
+		nodes = append(nodes, &n.Receiver)
+		nodes = appendParams(nodes, &n.Function)
+	case TMap:
+		nodes = appendType(nodes, n.Key)
+		nodes = appendType(nodes, n.Value)
+	case TInterface:
+		if n.Interface != nil {
+			nodes = append(nodes, n.Interface)
+		}
+	case LinearType:
+		nodes = appendType(nodes, n.NextType())
+	}
+	return nodes
+}
+
+func appendType(nodes []Node, t Type) []Node {
+	if t == nil {
+		return nodes
+	}
+	return append(nodes, t)
+}
+
+func appendParams(nodes []Node, fn *Function) []Node {
+	for i := range fn.Args {
+		nodes = append(nodes, &fn.Args[i])
+	}
+	for i := range fn.Results {
+		nodes = append(nodes, &fn.Results[i])
+	}
+	return nodes
+}
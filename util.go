@@ -2,12 +2,13 @@ package godecl
 
 import (
 	"fmt"
-	"go/ast"
+	"go/build"
 	astparser "go/parser"
 	"go/token"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 
 	"github.com/vetcher/godecl/types"
 )
@@ -28,7 +29,7 @@ func ParseFile(filename string) (*types.File, error) {
 	if err != nil {
 		return nil, err
 	}
-	info, err := ParseAstFile(tree, pp)
+	info, err := parseAstFile(tree, pp, filepath.Dir(path))
 	if err != nil {
 		return nil, fmt.Errorf("error when parsing info from file: %v", err)
 	}
@@ -46,7 +47,7 @@ func ParseFileWithoutGOPATH(filename string) (*types.File, error) {
 	if err != nil {
 		return nil, fmt.Errorf("error when parse file: %v", err)
 	}
-	info, err := ParseAstFile(tree, "")
+	info, err := parseAstFile(tree, "", filepath.Dir(path))
 	if err != nil {
 		return nil, fmt.Errorf("error when parsing info from file %s: %v", filename, err)
 	}
@@ -58,9 +59,102 @@ func MergeFiles(files []*types.File) (*types.Type, error) {
 	return nil, nil
 }
 
+// Parses all go files of package in directory path, except tests and files,
+// excluded by build constraints. Methods are linked with structures and types from other files.
 // Deprecated: use https://github.com/Vetcher/go-astra instead.
 func ParsePackage(path string) ([]*types.File, error) {
-	return nil, nil
+	pkg, err := build.ImportDir(path, 0)
+	if err != nil {
+		return nil, fmt.Errorf("can not import dir %s: %v", path, err)
+	}
+	files, err := parseFiles(path, append(pkg.GoFiles, pkg.CgoFiles...), packagePathOf(path))
+	if err != nil {
+		return nil, err
+	}
+	linkPackageMethods(files)
+	return files, nil
+}
+
+// Returns package path of directory or empty string, when it can't be resolved.
+func packagePathOf(dir string) string {
+	pp, err := ResolvePackagePath(filepath.Join(dir, "x.go"))
+	if err != nil {
+		return ""
+	}
+	return pp
+}
+
+func parseFile(filename, packagePath string) (*types.File, error) {
+	fset := token.NewFileSet()
+	tree, err := astparser.ParseFile(fset, filename, nil, astparser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("error when parse file: %v", err)
+	}
+	info, err := parseAstFile(tree, packagePath, filepath.Dir(filename))
+	if err != nil {
+		return nil, fmt.Errorf("error when parsing info from file %s: %v", filename, err)
+	}
+	return info, nil
+}
+
+var packageNames = struct {
+	sync.Mutex
+	names map[[2]string]string
+}{names: make(map[[2]string]string)}
+
+// Forgets cached names of packages, so their package clauses are read again.
+func resetPackageNames() {
+	packageNames.Lock()
+	defer packageNames.Unlock()
+	packageNames.names = make(map[[2]string]string)
+}
+
+// Returns name of imported package from its package clause, when package can be found
+// relative to srcDir, or name, assumed by import path, otherwise.
+func packageName(importPath, srcDir string) string {
+	if importPath == "C" {
+		return "C"
+	}
+	key := [2]string{importPath, srcDir}
+	packageNames.Lock()
+	defer packageNames.Unlock()
+	name, ok := packageNames.names[key]
+	if !ok {
+		name = readPackageName(importPath, srcDir)
+		if name == "" {
+			name = types.AssumedPackageName(importPath)
+		}
+		packageNames.names[key] = name
+	}
+	return name
+}
+
+// Reads package clause of the first file of package, which matches build constraints.
+// Returns empty string, when package can't be found.
+func readPackageName(importPath, srcDir string) string {
+	pkg, err := build.Import(importPath, srcDir, build.FindOnly)
+	if err != nil {
+		return ""
+	}
+	matches, err := filepath.Glob(filepath.Join(pkg.Dir, "*.go"))
+	if err != nil {
+		return ""
+	}
+	for _, match := range matches {
+		if strings.HasSuffix(match, "_test.go") {
+			continue
+		}
+		if ok, err := build.Default.MatchFile(pkg.Dir, filepath.Base(match)); err != nil || !ok {
+			continue
+		}
+		tree, err := astparser.ParseFile(token.NewFileSet(), match, nil, astparser.PackageClauseOnly)
+		// Package `documentation` is ignored by go tool.
+		if err != nil || tree.Name.Name == "documentation" {
+			continue
+		}
+		return tree.Name.Name
+	}
+	return ""
 }
 
 // Deprecated: use https://github.com/Vetcher/go-astra instead.
@@ -82,12 +176,3 @@ func ResolvePackagePath(outPath string) (string, error) {
 
 	return absOutPath[len(gopathSrc)+1:], nil
 }
-
-func namesOfIdents(idents []*ast.Ident) (res []string) {
-	for i := range idents {
-		if idents[i] != nil {
-			res = append(res, idents[i].Name)
-		}
-	}
-	return
-}
@@ -0,0 +1,287 @@
+package godecl
+
+import (
+	"go/build"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vetcher/godecl/types"
+)
+
+// Kind of change of declaration.
+type EventKind int
+
+const (
+	DeclAdded EventKind = iota
+	DeclRemoved
+	DeclChanged
+)
+
+var eventKindNames = map[EventKind]string{
+	DeclAdded:   "added",
+	DeclRemoved: "removed",
+	DeclChanged: "changed",
+}
+
+func (k EventKind) String() string {
+	return eventKindNames[k]
+}
+
+// Event describes change of one top-level declaration.
+type Event struct {
+	Kind EventKind
+	// Qualified name of declaration, e.g. `github.com/foo/bar.Service.Method`.
+	// When package path can't be resolved, directory is used instead of it.
+	Name string
+	// New declaration for added and changed declarations, old for removed.
+	// It is one of *types.Variable, *types.Function, *types.Method,
+	// *types.Struct, *types.Interface or *types.FileType.
+	Decl interface{}
+}
+
+// Watcher polls package directories and emits events about changed top-level declarations.
+// Only modified files are parsed again.
+// Both Events and Errors channels should be drained by user.
+type Watcher struct {
+	Events chan []Event
+	Errors chan error
+
+	interval time.Duration
+	dirs     []*watchedDir
+	stop     chan struct{}
+	done     chan struct{}
+	once     sync.Once
+}
+
+type watchedDir struct {
+	dir    string
+	prefix string
+	files  map[string]*watchedFile
+}
+
+type watchedFile struct {
+	modTime time.Time
+	size    int64
+	decls   map[string]interface{}
+}
+
+// Creates watcher for package directories and starts polling them each interval.
+// Initial state of packages is parsed before return, so returned error is a parse error.
+func NewWatcher(interval time.Duration, dirs ...string) (*Watcher, error) {
+	w := &Watcher{
+		Events:   make(chan []Event),
+		Errors:   make(chan error),
+		interval: interval,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	for _, dir := range dirs {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return nil, err
+		}
+		prefix := packagePathOf(abs)
+		if prefix == "" {
+			prefix = abs
+		}
+		wd := &watchedDir{dir: abs, prefix: prefix, files: make(map[string]*watchedFile)}
+		if _, err := wd.poll(); err != nil {
+			return nil, err
+		}
+		w.dirs = append(w.dirs, wd)
+	}
+	go w.run()
+	return w, nil
+}
+
+// Stops polling and closes channels. It may be called several times.
+func (w *Watcher) Close() error {
+	w.once.Do(func() { close(w.stop) })
+	<-w.done
+	return nil
+}
+
+func (w *Watcher) run() {
+	defer close(w.done)
+	defer close(w.Events)
+	defer close(w.Errors)
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+		}
+		var events []Event
+		for _, wd := range w.dirs {
+			evs, err := wd.poll()
+			if err != nil {
+				select {
+				case w.Errors <- err:
+				case <-w.stop:
+					return
+				}
+			}
+			events = append(events, evs...)
+		}
+		if len(events) == 0 {
+			continue
+		}
+		select {
+		case w.Events <- events:
+		case <-w.stop:
+			return
+		}
+	}
+}
+
+// Checks files of directory and returns events for files, which were changed since last poll.
+// Files with parse or stat errors keep their previous state and will be checked again on next poll.
+// Files, which are removed during poll, are reported as removed.
+func (wd *watchedDir) poll() ([]Event, error) {
+	// Package clauses of imported packages may be renamed since last poll.
+	resetPackageNames()
+	names, err := goFilesOf(wd.dir)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		before   = make(map[string]interface{})
+		after    = make(map[string]interface{})
+		firstErr error
+		seen     = make(map[string]bool)
+	)
+	for _, name := range names {
+		info, err := os.Stat(name)
+		if os.IsNotExist(err) {
+			// File is removed after listing of directory.
+			continue
+		}
+		seen[name] = true
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		old := wd.files[name]
+		if old != nil && old.modTime.Equal(info.ModTime()) && old.size == info.Size() {
+			continue
+		}
+		file, err := parseFile(name, packagePathOf(wd.dir))
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		if old != nil {
+			mergeDecls(before, old.decls)
+		}
+		decls := declarations(file, wd.prefix)
+		mergeDecls(after, decls)
+		wd.files[name] = &watchedFile{modTime: info.ModTime(), size: info.Size(), decls: decls}
+	}
+	for name, old := range wd.files {
+		if !seen[name] {
+			mergeDecls(before, old.decls)
+			delete(wd.files, name)
+		}
+	}
+	return diffDecls(before, after), firstErr
+}
+
+func goFilesOf(dir string) ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, name := range matches {
+		if strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		ok, err := build.Default.MatchFile(dir, filepath.Base(name))
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+func mergeDecls(dst, src map[string]interface{}) {
+	for k, v := range src {
+		dst[k] = v
+	}
+}
+
+func diffDecls(before, after map[string]interface{}) []Event {
+	var events []Event
+	for name, decl := range after {
+		old, ok := before[name]
+		switch {
+		case !ok:
+			events = append(events, Event{Kind: DeclAdded, Name: name, Decl: decl})
+		case !reflect.DeepEqual(old, decl):
+			events = append(events, Event{Kind: DeclChanged, Name: name, Decl: decl})
+		}
+	}
+	for name, decl := range before {
+		if _, ok := after[name]; !ok {
+			events = append(events, Event{Kind: DeclRemoved, Name: name, Decl: decl})
+		}
+	}
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].Name < events[j].Name
+	})
+	return events
+}
+
+// Collects top-level declarations of file by their qualified names.
+// Links between structures and methods are dropped, because methods are declarations by themselves.
+func declarations(file *types.File, prefix string) map[string]interface{} {
+	decls := make(map[string]interface{})
+	add := func(name string, decl interface{}) {
+		if name != "_" {
+			decls[prefix+"."+name] = decl
+		}
+	}
+	for i := range file.Constants {
+		add(file.Constants[i].Name, &file.Constants[i])
+	}
+	for i := range file.Vars {
+		add(file.Vars[i].Name, &file.Vars[i])
+	}
+	for i := range file.Functions {
+		add(file.Functions[i].Name, &file.Functions[i])
+	}
+	for i := range file.Interfaces {
+		add(file.Interfaces[i].Name, &file.Interfaces[i])
+	}
+	for i := range file.Structures {
+		s := file.Structures[i]
+		s.Methods = nil
+		add(s.Name, &s)
+	}
+	for i := range file.Types {
+		t := file.Types[i]
+		t.Methods = nil
+		add(t.Name, &t)
+	}
+	for i := range file.Methods {
+		m := &file.Methods[i]
+		if recv := types.TypeName(m.Receiver.Type); recv != nil {
+			add(*recv+"."+m.Name, m)
+		}
+	}
+	return decls
+}